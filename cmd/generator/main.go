@@ -1,7 +1,9 @@
 package main
 
 import (
+	"awesomeProject3/project/kafkaconfig"
 	"awesomeProject3/project/model"
+	"awesomeProject3/project/schema"
 	"context"
 	"encoding/json"
 	"log"
@@ -13,15 +15,17 @@ import (
 )
 
 func main() {
-	broker := getEnv("KAFKA_BROKER", "localhost:9092")
+	cfg, err := kafkaconfig.FromEnv("localhost:9092")
+	if err != nil {
+		log.Fatalf("Invalid Kafka config: %v", err)
+	}
 	topic := getEnv("KAFKA_TOPIC", "orders")
 
-	log.Printf("Start generator: broker=%s topic=%s", broker, topic)
+	log.Printf("Start generator: brokers=%v topic=%s", cfg.Brokers, topic)
 
-	w := &kafka.Writer{
-		Addr:     kafka.TCP(broker),
-		Topic:    topic,
-		Balancer: &kafka.LeastBytes{},
+	w, err := cfg.Writer(topic, &kafka.LeastBytes{})
+	if err != nil {
+		log.Fatalf("Can't configure Kafka writer: %v", err)
 	}
 
 	defer w.Close()
@@ -115,5 +119,6 @@ func randomOrder() model.Order {
 		SmID:            99,
 		DateCreated:     time.Now().UTC().Format(time.RFC3339),
 		OofShard:        "1",
+		SchemaVersion:   schema.CurrentVersion,
 	}
 }
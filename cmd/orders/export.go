@@ -0,0 +1,112 @@
+package main
+
+import (
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/ordercsv"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "ndjson", "output format: ndjson or csv")
+	out := fs.String("out", "", "output file (default stdout)")
+	from := fs.String("from", "", "only orders stored at or after this date (YYYY-MM-DD or RFC3339)")
+	to := fs.String("to", "", "only orders stored before this date (YYYY-MM-DD or RFC3339)")
+	customer := fs.String("customer", "", "only orders of this customer_id")
+	dbConn := fs.String("db", defaultDBConn(), "PostgreSQL connection string")
+	_ = fs.Parse(args)
+
+	var (
+		filter = database.ExportFilter{CustomerID: *customer}
+		err    error
+	)
+	if filter.From, err = parseDate(*from); err != nil {
+		return fmt.Errorf("-from: %w", err)
+	}
+	if filter.To, err = parseDate(*to); err != nil {
+		return fmt.Errorf("-to: %w", err)
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	bw := bufio.NewWriter(w)
+
+	var (
+		write func(model.Order) error
+		flush func() error
+	)
+	switch *format {
+	case "ndjson":
+		enc := json.NewEncoder(bw)
+		write = func(o model.Order) error { return enc.Encode(o) }
+		flush = func() error { return nil }
+	case "csv":
+		cw := ordercsv.NewWriter(bw)
+		write = cw.Write
+		flush = cw.Flush
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	db, err := openDB(*dbConn)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	n := 0
+	err = db.StreamOrders(ctx, filter, func(o model.Order) error {
+		n++
+		if n%10000 == 0 {
+			log.Printf("Exported %d orders", n)
+		}
+		return write(o)
+	})
+	if err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+
+	log.Printf("Export finished: %d orders", n)
+	return nil
+}
+
+func parseDate(v string) (time.Time, error) {
+	if v == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("expected YYYY-MM-DD or RFC3339 date")
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"awesomeProject3/project/pipeline"
+	"awesomeProject3/project/validation"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+type importStats struct {
+	read       int
+	imported   int
+	duplicates int
+	invalid    int
+}
+
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	file := fs.String("file", "", "NDJSON or JSON array file with orders (required)")
+	batchSize := fs.Int("batch", 500, "orders per insert transaction")
+	resume := fs.Bool("resume", false, "skip records already imported by a previous run")
+	dbConn := fs.String("db", defaultDBConn(), "PostgreSQL connection string")
+	_ = fs.Parse(args)
+
+	if *file == "" {
+		fs.Usage()
+		return errors.New("-file is required")
+	}
+	if *batchSize <= 0 {
+		return errors.New("-batch must be positive")
+	}
+
+	progressFile := *file + ".progress"
+	skip := 0
+	if *resume {
+		n, err := readProgress(progressFile)
+		if err != nil {
+			return err
+		}
+		skip = n
+		log.Printf("Resuming import after %d records", skip)
+	}
+
+	f, err := os.Open(*file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	records, err := newRecordReader(f)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDB(*dbConn)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	stats := importStats{read: skip}
+	inserted := 0
+	p := pipeline.New(
+		pipeline.Decode(pipeline.JSON),
+		pipeline.Validate(validation.ValidateOrder),
+		pipeline.PersistBatch(db, func(n int) { inserted += n }),
+	)
+
+	batch := make([]*pipeline.Envelope, 0, *batchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		stored := 0
+		inserted = 0
+		for i, err := range p.RunBatch(ctx, batch) {
+			switch {
+			case err == nil:
+				stored++
+			case pipeline.IsRejected(err):
+				stats.invalid++
+				log.Printf("Skipping record %s: %v", batch[i].Meta["record"], err)
+			default:
+				return err
+			}
+		}
+		// Orders already in the database, e.g. from an earlier import, pass
+		// the pipeline but aren't inserted again.
+		stats.imported += inserted
+		stats.duplicates += stored - inserted
+		stats.read += len(batch)
+		batch = batch[:0]
+
+		if err := writeProgress(progressFile, stats.read); err != nil {
+			return err
+		}
+		log.Printf("Progress: %d records read, %d imported, %d duplicates, %d invalid",
+			stats.read, stats.imported, stats.duplicates, stats.invalid)
+		return nil
+	}
+
+	for n := 0; ; n++ {
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted after %d records, rerun with -resume to continue", stats.read)
+		}
+
+		raw, err := records.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("record %d: %w", n+1, err)
+		}
+		if n < skip {
+			continue
+		}
+
+		batch = append(batch, &pipeline.Envelope{
+			Raw:    raw,
+			Source: pipeline.SourceFile,
+			Meta:   map[string]string{"record": strconv.Itoa(n + 1)},
+		})
+		if len(batch) == *batchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(progressFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	log.Printf("Import finished: %d records read, %d imported, %d duplicates, %d invalid",
+		stats.read, stats.imported, stats.duplicates, stats.invalid)
+	return nil
+}
+
+type recordReader interface {
+	Next() ([]byte, error)
+}
+
+// newRecordReader detects the file layout by its first non-space byte: a JSON
+// array is streamed element by element, anything else is read as NDJSON.
+func newRecordReader(r io.Reader) (recordReader, error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			if err == io.EOF {
+				return &ndjsonReader{sc: bufio.NewScanner(br)}, nil
+			}
+			return nil, err
+		}
+		if !isSpace(b[0]) {
+			if b[0] == '[' {
+				dec := json.NewDecoder(br)
+				if _, err := dec.Token(); err != nil {
+					return nil, err
+				}
+				return &arrayReader{dec: dec}, nil
+			}
+			sc := bufio.NewScanner(br)
+			sc.Buffer(make([]byte, 0, 64<<10), 16<<20)
+			return &ndjsonReader{sc: sc}, nil
+		}
+		_, _ = br.ReadByte()
+	}
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+type arrayReader struct {
+	dec *json.Decoder
+}
+
+func (r *arrayReader) Next() ([]byte, error) {
+	if !r.dec.More() {
+		return nil, io.EOF
+	}
+	var raw json.RawMessage
+	if err := r.dec.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+type ndjsonReader struct {
+	sc *bufio.Scanner
+}
+
+func (r *ndjsonReader) Next() ([]byte, error) {
+	for r.sc.Scan() {
+		line := bytes.TrimSpace(r.sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		return append([]byte(nil), line...), nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func readProgress(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("bad progress file %s: %w", path, err)
+	}
+	return n, nil
+}
+
+func writeProgress(path string, n int) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.Itoa(n)+"\n"), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/keyring"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func runRotateKeys(args []string) error {
+	fs := flag.NewFlagSet("rotate-keys", flag.ExitOnError)
+	keyringFile := fs.String("keyring", os.Getenv("KEYRING_FILE"), "keyring file; rows are re-encrypted with its active key")
+	batch := fs.Int("batch", 500, "rows re-encrypted per transaction")
+	dbConn := fs.String("db", defaultDBConn(), "PostgreSQL connection string")
+	_ = fs.Parse(args)
+
+	if *keyringFile == "" {
+		return fmt.Errorf("-keyring or KEYRING_FILE is required")
+	}
+	if *batch <= 0 {
+		return fmt.Errorf("-batch must be positive")
+	}
+
+	kr, err := keyring.Load(*keyringFile)
+	if err != nil {
+		return fmt.Errorf("load keyring: %w", err)
+	}
+	db, err := database.NewDB(*dbConn, database.WithKeyring(kr))
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	n, err := db.(*database.Database).RotateKeys(ctx, *batch)
+	log.Printf("Re-encrypted %d orders with key %s", n, kr.ActiveKeyID())
+	return err
+}
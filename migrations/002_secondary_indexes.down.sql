@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_orders_delivery_phone;
+DROP INDEX IF EXISTS idx_orders_delivery_email;
+DROP INDEX IF EXISTS idx_orders_payment_transaction;
+DROP INDEX IF EXISTS idx_orders_track_number;
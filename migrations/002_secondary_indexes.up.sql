@@ -0,0 +1,4 @@
+CREATE INDEX IF NOT EXISTS idx_orders_track_number ON orders ((data->>'track_number'));
+CREATE INDEX IF NOT EXISTS idx_orders_payment_transaction ON orders ((data->'payment'->>'transaction'));
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_email ON orders ((data->'delivery'->>'email'));
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_phone ON orders ((data->'delivery'->>'phone'));
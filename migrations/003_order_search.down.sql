@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_orders_search;
+DROP TRIGGER IF EXISTS orders_search_trigger ON orders;
+DROP FUNCTION IF EXISTS orders_search_update();
+DROP FUNCTION IF EXISTS orders_search_vector(JSONB);
+ALTER TABLE orders DROP COLUMN IF EXISTS search;
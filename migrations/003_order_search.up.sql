@@ -0,0 +1,27 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS search tsvector;
+
+CREATE OR REPLACE FUNCTION orders_search_vector(data JSONB) RETURNS tsvector AS $$
+    SELECT
+        setweight(to_tsvector('simple', coalesce(
+            (SELECT string_agg(concat_ws(' ', item->>'name', item->>'brand'), ' ')
+             FROM jsonb_array_elements(data->'items') AS item), '')), 'A') ||
+        setweight(to_tsvector('simple', concat_ws(' ',
+            data->'delivery'->>'city',
+            data->'delivery'->>'address')), 'B');
+$$ LANGUAGE SQL IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION orders_search_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search := orders_search_vector(NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS orders_search_trigger ON orders;
+CREATE TRIGGER orders_search_trigger
+    BEFORE INSERT OR UPDATE OF data ON orders
+    FOR EACH ROW EXECUTE FUNCTION orders_search_update();
+
+UPDATE orders SET search = orders_search_vector(data);
+
+CREATE INDEX IF NOT EXISTS idx_orders_search ON orders USING GIN (search);
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_orders_customer_date ON orders ((data->>'customer_id'), (data->>'date_created'));
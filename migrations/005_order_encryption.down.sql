@@ -0,0 +1,12 @@
+DROP INDEX IF EXISTS idx_orders_transaction_bidx;
+DROP INDEX IF EXISTS idx_orders_phone_bidx;
+DROP INDEX IF EXISTS idx_orders_email_bidx;
+DROP INDEX IF EXISTS idx_orders_key_id;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS transaction_bidx,
+    DROP COLUMN IF EXISTS phone_bidx,
+    DROP COLUMN IF EXISTS email_bidx,
+    DROP COLUMN IF EXISTS secret,
+    DROP COLUMN IF EXISTS dek,
+    DROP COLUMN IF EXISTS key_id;
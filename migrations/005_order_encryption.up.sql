@@ -0,0 +1,12 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS key_id TEXT,
+    ADD COLUMN IF NOT EXISTS dek BYTEA,
+    ADD COLUMN IF NOT EXISTS secret BYTEA,
+    ADD COLUMN IF NOT EXISTS email_bidx TEXT,
+    ADD COLUMN IF NOT EXISTS phone_bidx TEXT,
+    ADD COLUMN IF NOT EXISTS transaction_bidx TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_orders_key_id ON orders (key_id);
+CREATE INDEX IF NOT EXISTS idx_orders_email_bidx ON orders (email_bidx) WHERE email_bidx IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_phone_bidx ON orders (phone_bidx) WHERE phone_bidx IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_transaction_bidx ON orders (transaction_bidx) WHERE transaction_bidx IS NOT NULL;
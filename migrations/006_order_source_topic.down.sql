@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_source_topic;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS source_topic;
@@ -0,0 +1,3 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS source_topic TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_orders_source_topic ON orders (source_topic) WHERE source_topic IS NOT NULL;
@@ -0,0 +1,17 @@
+DROP FUNCTION IF EXISTS orders_search_query(tsquery, text[], text[]);
+
+CREATE OR REPLACE FUNCTION orders_search_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search := orders_search_vector(NEW.data);
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS orders_search_trigger ON orders;
+CREATE TRIGGER orders_search_trigger
+    BEFORE INSERT OR UPDATE OF data ON orders
+    FOR EACH ROW EXECUTE FUNCTION orders_search_update();
+
+ALTER TABLE orders DROP COLUMN IF EXISTS address_search;
+
+UPDATE orders SET search = orders_search_vector(data);
@@ -0,0 +1,29 @@
+-- Encrypted rows have the address blanked in data. The application writes a
+-- keyed hash (blind index) of each address word into address_search, without
+-- positions, and the trigger merges them into search.
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS address_search tsvector;
+
+CREATE OR REPLACE FUNCTION orders_search_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search := orders_search_vector(NEW.data) ||
+        setweight(coalesce(NEW.address_search, ''::tsvector), 'B');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS orders_search_trigger ON orders;
+CREATE TRIGGER orders_search_trigger
+    BEFORE INSERT OR UPDATE OF data, address_search ON orders
+    FOR EACH ROW EXECUTE FUNCTION orders_search_update();
+
+-- orders_search_query lets each words[i] of q also match its blind index
+-- hashes[i], so queries find encrypted addresses too.
+CREATE OR REPLACE FUNCTION orders_search_query(q tsquery, words text[], hashes text[]) RETURNS tsquery AS $$
+BEGIN
+    FOR i IN 1 .. coalesce(array_length(words, 1), 0) LOOP
+        q := ts_rewrite(q, quote_literal(words[i])::tsquery,
+            quote_literal(words[i])::tsquery || quote_literal(hashes[i])::tsquery);
+    END LOOP;
+    RETURN q;
+END;
+$$ LANGUAGE plpgsql IMMUTABLE;
@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func request(header, value string) *http.Request {
+	r := httptest.NewRequest("GET", "/", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	return r
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, key any, kid string, c jwt.MapClaims) string {
+	t.Helper()
+	tok := jwt.NewWithClaims(method, c)
+	if kid != "" {
+		tok.Header["kid"] = kid
+	}
+	s, err := tok.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return s
+}
+
+func TestNew_DisabledWithoutConfig(t *testing.T) {
+	if New(Config{}) != nil {
+		t.Fatalf("expected nil authenticator without config")
+	}
+}
+
+func TestAuthenticate_APIKey(t *testing.T) {
+	a := New(Config{APIKeys: map[string]Principal{"k1": {Subject: "svc", Scopes: []string{ScopeRead}}}})
+
+	p, err := a.Authenticate(request(APIKeyHeader, "k1"))
+	if err != nil || p.Subject != "svc" || !p.Has(ScopeRead) || p.Has(ScopeWrite) {
+		t.Fatalf("unexpected principal %+v, err %v", p, err)
+	}
+
+	if _, err := a.Authenticate(request(APIKeyHeader, "k2")); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected invalid credentials, got %v", err)
+	}
+	if _, err := a.Authenticate(request("", "")); !errors.Is(err, ErrNoCredentials) {
+		t.Fatalf("expected no credentials, got %v", err)
+	}
+}
+
+func TestAuthenticate_HS256(t *testing.T) {
+	secret := []byte("s3cret")
+	a := New(Config{HMACSecret: secret, Issuer: "shop"})
+
+	token := sign(t, jwt.SigningMethodHS256, secret, "", jwt.MapClaims{
+		"sub": "user-1", "iss": "shop", "scope": "orders:read", "customer_id": "c-1",
+		"exp": time.Now().Add(time.Minute).Unix(),
+	})
+	p, err := a.Authenticate(request("Authorization", "Bearer "+token))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Subject != "user-1" || p.CustomerID != "c-1" || !p.Has(ScopeRead) {
+		t.Fatalf("unexpected principal %+v", p)
+	}
+
+	expired := sign(t, jwt.SigningMethodHS256, secret, "", jwt.MapClaims{
+		"iss": "shop", "exp": time.Now().Add(-time.Minute).Unix(),
+	})
+	if _, err := a.Authenticate(request("Authorization", "Bearer "+expired)); !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected expired token to be rejected, got %v", err)
+	}
+
+	noExp := sign(t, jwt.SigningMethodHS256, secret, "", jwt.MapClaims{"iss": "shop"})
+	if _, err := a.Authenticate(request("Authorization", "Bearer "+noExp)); err == nil {
+		t.Fatalf("expected token without exp to be rejected")
+	}
+
+	otherIssuer := sign(t, jwt.SigningMethodHS256, secret, "", jwt.MapClaims{
+		"iss": "evil", "exp": time.Now().Add(time.Minute).Unix(),
+	})
+	if _, err := a.Authenticate(request("Authorization", "Bearer "+otherIssuer)); err == nil {
+		t.Fatalf("expected token from other issuer to be rejected")
+	}
+}
+
+func TestAuthenticate_RS256FromJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	jwks, _ := json.Marshal(map[string]any{"keys": []map[string]string{{
+		"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}})
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, jwks, 0o600); err != nil {
+		t.Fatalf("write jwks: %v", err)
+	}
+
+	keys, err := LoadJWKS(path)
+	if err != nil {
+		t.Fatalf("load jwks: %v", err)
+	}
+	a := New(Config{RSAKeys: keys})
+
+	claims := jwt.MapClaims{"scope": "admin", "exp": time.Now().Add(time.Minute).Unix()}
+	p, err := a.Authenticate(request("Authorization", "Bearer "+sign(t, jwt.SigningMethodRS256, key, "k1", claims)))
+	if err != nil || !p.Has(ScopeWrite) {
+		t.Fatalf("expected admin token to be accepted, got %+v %v", p, err)
+	}
+
+	if _, err := a.Authenticate(request("Authorization", "Bearer "+sign(t, jwt.SigningMethodRS256, key, "k2", claims))); err == nil {
+		t.Fatalf("expected unknown kid to be rejected")
+	}
+
+	// HS256 is not configured, so a token signed with the public modulus as
+	// an HMAC secret must not be accepted.
+	forged := sign(t, jwt.SigningMethodHS256, key.N.Bytes(), "k1", claims)
+	if _, err := a.Authenticate(request("Authorization", "Bearer "+forged)); err == nil {
+		t.Fatalf("expected HS256 token to be rejected")
+	}
+}
+
+func TestAuthenticate_APIKeysOnlyRejectsJWT(t *testing.T) {
+	a := New(Config{APIKeys: map[string]Principal{"k": {Subject: "svc"}}})
+	claims := jwt.MapClaims{"sub": "attacker", "scope": ScopeAdmin, "exp": time.Now().Add(time.Hour).Unix()}
+
+	// Signed by hand, as an attacker would: HMAC-SHA256 with an empty key.
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SigningString()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mac := hmac.New(sha256.New, nil)
+	mac.Write([]byte(unsigned))
+	token := unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	p, err := a.Authenticate(request("Authorization", "Bearer "+token))
+	if !errors.Is(err, ErrInvalidCredentials) {
+		t.Fatalf("expected empty-key token to be rejected, got principal=%+v err=%v", p, err)
+	}
+}
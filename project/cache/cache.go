@@ -12,31 +12,122 @@ type CC interface {
 	Delete(orderUID string)
 }
 
+// TTLPolicy picks a TTL for an order when it is stored with Set.
+// Returning zero falls back to the cache default.
+type TTLPolicy func(o model.Order) time.Duration
+
+type Option func(*options)
+
+type options struct {
+	sliding bool
+	policy  TTLPolicy
+	now     func() time.Time
+}
+
+func (opts options) ttlFor(o model.Order, def time.Duration) time.Duration {
+	if opts.policy != nil {
+		if ttl := opts.policy(o); ttl > 0 {
+			return ttl
+		}
+	}
+	return def
+}
+
+// WithSliding makes Get restart the TTL of the entry it returns.
+func WithSliding() Option {
+	return func(o *options) { o.sliding = true }
+}
+
+func WithTTLPolicy(p TTLPolicy) Option {
+	return func(o *options) { o.policy = p }
+}
+
+// withClock replaces time.Now. It is set before the janitor starts, so
+// tests can use a fake clock without racing with it.
+func withClock(now func() time.Time) Option {
+	return func(o *options) { o.now = now }
+}
+
+// ItemStatusPolicy keeps an order for the TTL configured for its item status.
+// An order only gets a status TTL when every item has a configured status;
+// with mixed statuses the shortest of them applies.
+func ItemStatusPolicy(ttls map[int]time.Duration) TTLPolicy {
+	return func(o model.Order) time.Duration {
+		var ttl time.Duration
+		for i, item := range o.Items {
+			d, ok := ttls[item.Status]
+			if !ok {
+				return 0
+			}
+			if i == 0 || d < ttl {
+				ttl = d
+			}
+		}
+		return ttl
+	}
+}
+
 type Cache struct {
-	mu     sync.RWMutex
-	orders map[string]cachedOrder
-	ttl    time.Duration
+	mu        sync.RWMutex
+	orders    map[string]cachedOrder
+	ttl       time.Duration
+	opts      options
+	now       func() time.Time
+	done      chan struct{}
+	closeOnce sync.Once
 }
 
 type cachedOrder struct {
 	order     model.Order
 	timestamp time.Time
+	ttl       time.Duration
+	stored    time.Time
+	etag      *etagMemo
+}
+
+func newCachedOrder(o model.Order, now time.Time, ttl time.Duration) cachedOrder {
+	return cachedOrder{
+		order:     o,
+		timestamp: now,
+		ttl:       ttl,
+		stored:    now,
+		etag:      &etagMemo{},
+	}
 }
 
-func New(ttl time.Duration) *Cache {
+func (co cachedOrder) expired(now time.Time) bool {
+	return now.Sub(co.timestamp) > co.ttl
+}
+
+func New(ttl time.Duration, opts ...Option) *Cache {
 	c := &Cache{
 		orders: make(map[string]cachedOrder),
 		ttl:    ttl,
+		now:    time.Now,
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	if c.opts.now != nil {
+		c.now = c.opts.now
 	}
 
 	go func() {
 		ticker := time.NewTicker(ttl)
 		defer ticker.Stop()
 
-		for range ticker.C {
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-ticker.C:
+			}
+
 			c.mu.Lock()
+			now := c.now()
 			for id, co := range c.orders {
-				if time.Since(co.timestamp) > c.ttl {
+				if co.expired(now) {
 					delete(c.orders, id)
 				}
 			}
@@ -47,32 +138,87 @@ func New(ttl time.Duration) *Cache {
 
 }
 
+// Close stops the janitor. Expired entries are still dropped on Get.
+func (c *Cache) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
 func (c *Cache) Get(orderUID string) (model.Order, bool) {
+	co, ok := c.get(orderUID)
+	return co.order, ok
+}
+
+func (c *Cache) GetEntry(orderUID string) (Entry, bool) {
+	co, ok := c.get(orderUID)
+	if !ok {
+		return Entry{}, false
+	}
+	return co.entry(), true
+}
+
+func (c *Cache) get(orderUID string) (cachedOrder, bool) {
+	if c.opts.sliding {
+		return c.getSliding(orderUID)
+	}
+
 	c.mu.RLock()
 	co, ok := c.orders[orderUID]
 	c.mu.RUnlock()
 
 	if !ok {
-		return model.Order{}, false
+		return cachedOrder{}, false
 	}
 
-	if time.Since(co.timestamp) > c.ttl {
+	now := c.now()
+	if co.expired(now) {
+		// Set may have replaced the entry since it was read.
 		c.mu.Lock()
-		delete(c.orders, orderUID)
+		if co, ok := c.orders[orderUID]; ok && co.expired(now) {
+			delete(c.orders, orderUID)
+		}
 		c.mu.Unlock()
-		return model.Order{}, false
+		return cachedOrder{}, false
 	}
 
-	return co.order, true
+	return co, true
 }
 
-func (c *Cache) Set(orderUID string, o model.Order) {
+func (c *Cache) getSliding(orderUID string) (cachedOrder, bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.orders[orderUID] = cachedOrder{
-		order:     o,
-		timestamp: time.Now(),
+
+	co, ok := c.orders[orderUID]
+	if !ok {
+		return cachedOrder{}, false
 	}
+
+	now := c.now()
+	if co.expired(now) {
+		delete(c.orders, orderUID)
+		return cachedOrder{}, false
+	}
+
+	co.timestamp = now
+	c.orders[orderUID] = co
+	return co, true
+}
+
+func (c *Cache) Set(orderUID string, o model.Order) {
+	c.SetWithTTL(orderUID, o, c.ttlFor(o))
+}
+
+func (c *Cache) SetWithTTL(orderUID string, o model.Order, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.orders[orderUID] = newCachedOrder(o, c.now(), ttl)
+}
+
+func (c *Cache) ttlFor(o model.Order) time.Duration {
+	return c.opts.ttlFor(o, c.ttl)
 }
 
 func (c *Cache) Delete(orderUID string) {
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"awesomeProject3/project/model"
+	"testing"
+	"time"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (f *fakeClock) Now() time.Time { return f.t }
+
+func (f *fakeClock) Advance(d time.Duration) { f.t = f.t.Add(d) }
+
+func newTestCache(ttl time.Duration, opts ...Option) (*Cache, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := New(ttl, append(opts, withClock(clock.Now))...)
+	return c, clock
+}
+
+func TestCache_Set_ExpiresAfterDefaultTTL(t *testing.T) {
+	c, clock := newTestCache(time.Minute)
+
+	c.Set("a", model.Order{OrderUID: "a"})
+
+	clock.Advance(59 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected hit before ttl")
+	}
+
+	clock.Advance(2 * time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("expected miss after ttl")
+	}
+}
+
+func TestCache_SetWithTTL_OverridesDefault(t *testing.T) {
+	c, clock := newTestCache(time.Minute)
+
+	c.SetWithTTL("long", model.Order{OrderUID: "long"}, time.Hour)
+	c.Set("short", model.Order{OrderUID: "short"})
+
+	clock.Advance(10 * time.Minute)
+	if _, ok := c.Get("long"); !ok {
+		t.Fatalf("expected entry with own ttl to survive")
+	}
+	if _, ok := c.Get("short"); ok {
+		t.Fatalf("expected entry with default ttl to expire")
+	}
+}
+
+func TestCache_Sliding_GetRefreshesTTL(t *testing.T) {
+	c, clock := newTestCache(time.Minute, WithSliding())
+
+	c.Set("a", model.Order{OrderUID: "a"})
+
+	for i := 0; i < 5; i++ {
+		clock.Advance(40 * time.Second)
+		if _, ok := c.Get("a"); !ok {
+			t.Fatalf("expected hit on read %d with sliding ttl", i)
+		}
+	}
+
+	clock.Advance(61 * time.Second)
+	if _, ok := c.Get("a"); ok {
+		t.Fatalf("expected miss after idle period longer than ttl")
+	}
+}
+
+func TestCache_TTLPolicy_ByItemStatus(t *testing.T) {
+	c, clock := newTestCache(time.Minute, WithTTLPolicy(ItemStatusPolicy(map[int]time.Duration{
+		202: time.Hour,
+	})))
+
+	delivered := model.Order{OrderUID: "d", Items: []model.Items{{Status: 202}, {Status: 202}}}
+	mixed := model.Order{OrderUID: "m", Items: []model.Items{{Status: 202}, {Status: 100}}}
+
+	c.Set(delivered.OrderUID, delivered)
+	c.Set(mixed.OrderUID, mixed)
+
+	clock.Advance(10 * time.Minute)
+	if _, ok := c.Get("d"); !ok {
+		t.Fatalf("expected delivered order to use policy ttl")
+	}
+	if _, ok := c.Get("m"); ok {
+		t.Fatalf("expected order with unconfigured status to use default ttl")
+	}
+}
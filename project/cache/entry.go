@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"awesomeProject3/project/model"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Entry is a cached order with the validators used for conditional GETs.
+type Entry struct {
+	Order    model.Order
+	ETag     string
+	Modified time.Time
+}
+
+// EntryCache is implemented by caches that memoize the order hash per entry.
+type EntryCache interface {
+	GetEntry(orderUID string) (Entry, bool)
+}
+
+// ETag hashes the JSON form of the order. The result is not quoted.
+func ETag(o model.Order) string {
+	data, _ := json.Marshal(o)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// etagMemo is shared by copies of a cachedOrder, so the hash is computed at
+// most once per Set.
+type etagMemo struct {
+	once sync.Once
+	etag string
+}
+
+func (m *etagMemo) get(o model.Order) string {
+	m.once.Do(func() { m.etag = ETag(o) })
+	return m.etag
+}
+
+func (co cachedOrder) entry() Entry {
+	modified := co.order.CreatedAt
+	if modified.IsZero() {
+		modified = co.stored
+	}
+	return Entry{
+		Order:    co.order,
+		ETag:     co.etag.get(co.order),
+		Modified: modified,
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"awesomeProject3/project/model"
+	"testing"
+	"time"
+)
+
+func TestCache_GetEntry_MemoizesETagPerSet(t *testing.T) {
+	c, clock := newTestCache(time.Minute, WithSliding())
+
+	c.Set("a", model.Order{OrderUID: "a", TrackNumber: "T1"})
+	first, ok := c.GetEntry("a")
+	if !ok {
+		t.Fatalf("expected hit")
+	}
+	if first.ETag != ETag(first.Order) {
+		t.Fatalf("expected etag of the order, got %q", first.ETag)
+	}
+	if !first.Modified.Equal(clock.Now()) {
+		t.Fatalf("expected modified to fall back to set time, got %v", first.Modified)
+	}
+
+	clock.Advance(time.Second)
+	if again, _ := c.GetEntry("a"); again.ETag != first.ETag || !again.Modified.Equal(first.Modified) {
+		t.Fatalf("expected sliding get to keep validators, got %+v", again)
+	}
+
+	c.Set("a", model.Order{OrderUID: "a", TrackNumber: "T2"})
+	if updated, _ := c.GetEntry("a"); updated.ETag == first.ETag {
+		t.Fatalf("expected new etag after Set")
+	}
+}
+
+func TestShardedCache_GetEntry_UsesCreatedAt(t *testing.T) {
+	c := NewSharded(time.Minute, 4)
+	created := time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+
+	c.Set("a", model.Order{OrderUID: "a", CreatedAt: created})
+
+	e, ok := c.GetEntry("a")
+	if !ok {
+		t.Fatalf("expected hit")
+	}
+	if !e.Modified.Equal(created) {
+		t.Fatalf("expected modified %v, got %v", created, e.Modified)
+	}
+}
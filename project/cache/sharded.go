@@ -0,0 +1,206 @@
+package cache
+
+import (
+	"awesomeProject3/project/model"
+	"sync"
+	"time"
+)
+
+const sweepBatch = 256
+
+// ShardedCache spreads entries over independently locked shards picked by key
+// hash. The janitor sweeps one shard per tick, so a full pass over the cache
+// takes one TTL and never holds more than one shard lock at a time. Close
+// stops the janitor.
+type ShardedCache struct {
+	shards    []*shard
+	mask      uint32
+	ttl       time.Duration
+	opts      options
+	now       func() time.Time
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+type shard struct {
+	mu     sync.RWMutex
+	orders map[string]cachedOrder
+}
+
+func NewSharded(ttl time.Duration, shards int, opts ...Option) *ShardedCache {
+	n := 1
+	for n < shards {
+		n <<= 1
+	}
+
+	c := &ShardedCache{
+		shards: make([]*shard, n),
+		mask:   uint32(n - 1),
+		ttl:    ttl,
+		now:    time.Now,
+		done:   make(chan struct{}),
+	}
+	for i := range c.shards {
+		c.shards[i] = &shard{orders: make(map[string]cachedOrder)}
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+	if c.opts.now != nil {
+		c.now = c.opts.now
+	}
+
+	interval := ttl / time.Duration(n)
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		next := 0
+		for {
+			select {
+			case <-c.done:
+				return
+			case <-ticker.C:
+				c.shards[next].sweep(c.now)
+				next = (next + 1) % len(c.shards)
+			}
+		}
+	}()
+	return c
+}
+
+// Close stops the janitor. Expired entries are still dropped on Get.
+func (c *ShardedCache) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+}
+
+func (c *ShardedCache) shardFor(orderUID string) *shard {
+	// FNV-1a, inlined to keep Get allocation free.
+	h := uint32(2166136261)
+	for i := 0; i < len(orderUID); i++ {
+		h ^= uint32(orderUID[i])
+		h *= 16777619
+	}
+	return c.shards[h&c.mask]
+}
+
+func (c *ShardedCache) Get(orderUID string) (model.Order, bool) {
+	co, ok := c.get(orderUID)
+	return co.order, ok
+}
+
+func (c *ShardedCache) GetEntry(orderUID string) (Entry, bool) {
+	co, ok := c.get(orderUID)
+	if !ok {
+		return Entry{}, false
+	}
+	return co.entry(), true
+}
+
+func (c *ShardedCache) get(orderUID string) (cachedOrder, bool) {
+	s := c.shardFor(orderUID)
+	if c.opts.sliding {
+		return s.getSliding(orderUID, c.now())
+	}
+
+	s.mu.RLock()
+	co, ok := s.orders[orderUID]
+	s.mu.RUnlock()
+
+	if !ok {
+		return cachedOrder{}, false
+	}
+
+	now := c.now()
+	if co.expired(now) {
+		s.deleteExpired(orderUID, now)
+		return cachedOrder{}, false
+	}
+
+	return co, true
+}
+
+func (c *ShardedCache) Set(orderUID string, o model.Order) {
+	c.SetWithTTL(orderUID, o, c.opts.ttlFor(o, c.ttl))
+}
+
+func (c *ShardedCache) SetWithTTL(orderUID string, o model.Order, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+
+	s := c.shardFor(orderUID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.orders[orderUID] = newCachedOrder(o, c.now(), ttl)
+}
+
+func (c *ShardedCache) Delete(orderUID string) {
+	s := c.shardFor(orderUID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.orders, orderUID)
+}
+
+func (s *shard) getSliding(orderUID string, now time.Time) (cachedOrder, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	co, ok := s.orders[orderUID]
+	if !ok {
+		return cachedOrder{}, false
+	}
+
+	if co.expired(now) {
+		delete(s.orders, orderUID)
+		return cachedOrder{}, false
+	}
+
+	co.timestamp = now
+	s.orders[orderUID] = co
+	return co, true
+}
+
+// deleteExpired re-checks the entry under the write lock: it may have been
+// replaced by Set between the read and the delete.
+func (s *shard) deleteExpired(orderUID string, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if co, ok := s.orders[orderUID]; ok && co.expired(now) {
+		delete(s.orders, orderUID)
+	}
+}
+
+// sweep collects expired keys under the read lock and deletes them in small
+// batches, so readers of the shard are only blocked for short periods.
+func (s *shard) sweep(clock func() time.Time) {
+	now := clock()
+	expired := make([]string, 0, sweepBatch)
+
+	s.mu.RLock()
+	for id, co := range s.orders {
+		if co.expired(now) {
+			expired = append(expired, id)
+		}
+	}
+	s.mu.RUnlock()
+
+	for len(expired) > 0 {
+		n := min(len(expired), sweepBatch)
+
+		s.mu.Lock()
+		for _, id := range expired[:n] {
+			if co, ok := s.orders[id]; ok && co.expired(now) {
+				delete(s.orders, id)
+			}
+		}
+		s.mu.Unlock()
+
+		expired = expired[n:]
+	}
+}
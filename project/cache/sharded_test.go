@@ -0,0 +1,147 @@
+package cache
+
+import (
+	"awesomeProject3/project/model"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestSharded(ttl time.Duration, opts ...Option) (*ShardedCache, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c := NewSharded(ttl, 8, append(opts, withClock(clock.Now))...)
+	return c, clock
+}
+
+func TestShardedCache_SetGetDelete(t *testing.T) {
+	c, _ := newTestSharded(time.Minute)
+
+	for i := 0; i < 100; i++ {
+		uid := "order-" + strconv.Itoa(i)
+		c.Set(uid, model.Order{OrderUID: uid})
+	}
+
+	for i := 0; i < 100; i++ {
+		uid := "order-" + strconv.Itoa(i)
+		got, ok := c.Get(uid)
+		if !ok || got.OrderUID != uid {
+			t.Fatalf("expected hit for %s, got ok=%v uid=%q", uid, ok, got.OrderUID)
+		}
+	}
+
+	c.Delete("order-1")
+	if _, ok := c.Get("order-1"); ok {
+		t.Fatalf("expected miss after delete")
+	}
+}
+
+func TestShardedCache_ExpiryAndSliding(t *testing.T) {
+	c, clock := newTestSharded(time.Minute, WithSliding())
+
+	c.Set("a", model.Order{OrderUID: "a"})
+	c.SetWithTTL("b", model.Order{OrderUID: "b"}, 10*time.Second)
+
+	clock.Advance(40 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected hit before ttl")
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Fatalf("expected entry with own ttl to expire")
+	}
+
+	clock.Advance(40 * time.Second)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected sliding ttl to be refreshed by previous get")
+	}
+}
+
+func TestShardedCache_CloseStopsJanitor(t *testing.T) {
+	c, _ := newTestSharded(time.Minute)
+
+	c.Close()
+	c.Close()
+	select {
+	case <-c.done:
+	default:
+		t.Fatalf("expected janitor to be signalled")
+	}
+
+	c.Set("a", model.Order{OrderUID: "a"})
+	if _, ok := c.Get("a"); !ok {
+		t.Fatalf("expected cache to keep working after Close")
+	}
+}
+
+func TestShard_Sweep_RemovesOnlyExpired(t *testing.T) {
+	c, clock := newTestSharded(time.Minute)
+
+	for i := 0; i < 1000; i++ {
+		c.Set("old-"+strconv.Itoa(i), model.Order{})
+	}
+	clock.Advance(2 * time.Minute)
+	c.Set("fresh", model.Order{OrderUID: "fresh"})
+
+	for _, s := range c.shards {
+		s.sweep(c.now)
+	}
+
+	total := 0
+	for _, s := range c.shards {
+		total += len(s.orders)
+	}
+	if total != 1 {
+		t.Fatalf("expected 1 entry after sweep, got %d", total)
+	}
+	if _, ok := c.Get("fresh"); !ok {
+		t.Fatalf("expected fresh entry to survive sweep")
+	}
+}
+
+const benchKeys = 10000
+
+func benchmarkMixed(b *testing.B, c CC, writePercent int) {
+	keys := make([]string, benchKeys)
+	for i := range keys {
+		keys[i] = "order-" + strconv.Itoa(i)
+		c.Set(keys[i], model.Order{OrderUID: keys[i]})
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := keys[(i*7919)%benchKeys]
+			if i%100 < writePercent {
+				c.Set(key, model.Order{OrderUID: key})
+			} else {
+				c.Get(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkCache_Mixed90_10(b *testing.B) {
+	benchmarkMixed(b, New(time.Hour), 10)
+}
+
+func BenchmarkShardedCache_Mixed90_10(b *testing.B) {
+	benchmarkMixed(b, NewSharded(time.Hour, 64), 10)
+}
+
+func BenchmarkCache_Mixed50_50(b *testing.B) {
+	benchmarkMixed(b, New(time.Hour), 50)
+}
+
+func BenchmarkShardedCache_Mixed50_50(b *testing.B) {
+	benchmarkMixed(b, NewSharded(time.Hour, 64), 50)
+}
+
+func BenchmarkCache_MixedSliding(b *testing.B) {
+	benchmarkMixed(b, New(time.Hour, WithSliding()), 10)
+}
+
+func BenchmarkShardedCache_MixedSliding(b *testing.B) {
+	benchmarkMixed(b, NewSharded(time.Hour, 64, WithSliding()), 10)
+}
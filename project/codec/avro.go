@@ -0,0 +1,95 @@
+package codec
+
+import (
+	"awesomeProject3/project/model"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/hamba/avro/v2"
+)
+
+var ErrNotConfluentAvro = errors.New("payload is not in confluent avro wire format")
+
+// Avro decodes the Confluent wire format: a zero magic byte, a big-endian
+// schema ID and the Avro binary body written with that schema.
+type Avro struct {
+	Registry SchemaRegistry
+
+	mu      sync.RWMutex
+	schemas map[int]avro.Schema
+}
+
+func NewAvro(registry SchemaRegistry) *Avro {
+	return &Avro{Registry: registry, schemas: make(map[int]avro.Schema)}
+}
+
+func (a *Avro) Decode(ctx context.Context, data []byte, meta map[string]string) (model.Order, error) {
+	if len(data) < 5 || data[0] != 0 {
+		return model.Order{}, ErrNotConfluentAvro
+	}
+	id := int(binary.BigEndian.Uint32(data[1:5]))
+
+	schema, err := a.schema(ctx, id)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	var record map[string]any
+	if err := avro.Unmarshal(schema, data[5:], &record); err != nil {
+		return model.Order{}, fmt.Errorf("avro schema %d: %w", id, err)
+	}
+
+	// Field names of the Avro schema follow the JSON names of model.Order, so
+	// the generic record maps onto the struct through its JSON tags.
+	jsonData, err := json.Marshal(record)
+	if err != nil {
+		return model.Order{}, err
+	}
+	var o model.Order
+	if err := json.Unmarshal(jsonData, &o); err != nil {
+		return model.Order{}, fmt.Errorf("avro schema %d: %w", id, err)
+	}
+	return o, nil
+}
+
+func (a *Avro) schema(ctx context.Context, id int) (avro.Schema, error) {
+	a.mu.RLock()
+	s, ok := a.schemas[id]
+	a.mu.RUnlock()
+	if ok {
+		return s, nil
+	}
+
+	text, err := a.Registry.Schema(ctx, id)
+	if errors.Is(err, ErrSchemaNotFound) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, &RegistryError{ID: id, Err: err}
+	}
+	s, err = avro.Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse schema %d: %w", id, err)
+	}
+
+	a.mu.Lock()
+	a.schemas[id] = s
+	a.mu.Unlock()
+	return s, nil
+}
+
+// EncodeConfluent writes v in the Confluent wire format. It is the inverse of
+// Avro.Decode and is used by producers and tests.
+func EncodeConfluent(schema avro.Schema, id int, v any) ([]byte, error) {
+	body, err := avro.Marshal(schema, v)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 5, 5+len(body))
+	binary.BigEndian.PutUint32(out[1:5], uint32(id))
+	return append(out, body...), nil
+}
@@ -0,0 +1,191 @@
+package codec
+
+import (
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/orderpb"
+	"awesomeProject3/project/schema"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	FormatJSON     = "json"
+	FormatProtobuf = "protobuf"
+	FormatAvro     = "avro"
+)
+
+// ContentTypeHeader is the Kafka message header producers can set to pick a
+// decoder per message. Messages without it use the default format.
+const ContentTypeHeader = "content-type"
+
+// Decoder turns a message value into an order. meta carries the message
+// headers (lower-cased keys) and may be nil.
+type Decoder interface {
+	Decode(ctx context.Context, data []byte, meta map[string]string) (model.Order, error)
+}
+
+type DecoderFunc func(ctx context.Context, data []byte, meta map[string]string) (model.Order, error)
+
+func (f DecoderFunc) Decode(ctx context.Context, data []byte, meta map[string]string) (model.Order, error) {
+	return f(ctx, data, meta)
+}
+
+type JSONMode string
+
+const (
+	// JSONLenient ignores unknown fields, like encoding/json does.
+	JSONLenient JSONMode = "lenient"
+	// JSONReport accepts unknown fields but reports them.
+	JSONReport JSONMode = "report"
+	// JSONStrict rejects payloads with unknown fields.
+	JSONStrict JSONMode = "strict"
+)
+
+func ParseJSONMode(s string) (JSONMode, error) {
+	switch m := JSONMode(strings.ToLower(strings.TrimSpace(s))); m {
+	case "":
+		return JSONLenient, nil
+	case JSONLenient, JSONReport, JSONStrict:
+		return m, nil
+	}
+	return "", fmt.Errorf("unknown json mode %q", s)
+}
+
+var unknownFieldsVar = expvar.NewMap("codec_unknown_fields")
+
+// JSONDecoder upcasts older payload versions before decoding. The version is
+// taken from the schema_version header when present, otherwise from the
+// payload. The unknown-field mode can be set per topic.
+type JSONDecoder struct {
+	Mode       JSONMode
+	TopicModes map[string]JSONMode
+	// OnUnknown is called in report mode. By default unknown fields are
+	// logged and messages with them are counted per topic in the
+	// codec_unknown_fields expvar.
+	OnUnknown func(topic string, fields []string)
+}
+
+var JSON Decoder = &JSONDecoder{Mode: JSONLenient}
+
+func (d *JSONDecoder) mode(topic string) JSONMode {
+	if m, ok := d.TopicModes[topic]; ok {
+		return m
+	}
+	if d.Mode == "" {
+		return JSONLenient
+	}
+	return d.Mode
+}
+
+func (d *JSONDecoder) Decode(ctx context.Context, data []byte, meta map[string]string) (model.Order, error) {
+	version := 0
+	if h := meta[schema.VersionField]; h != "" {
+		v, err := schema.ParseVersion(h)
+		if err != nil {
+			return model.Order{}, err
+		}
+		version = v
+	}
+
+	data, err := schema.Upcast(data, version)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	topic := meta["topic"]
+	if mode := d.mode(topic); mode != JSONLenient {
+		fields, err := UnknownFields(data)
+		if err != nil {
+			return model.Order{}, err
+		}
+		if len(fields) > 0 {
+			if mode == JSONStrict {
+				return model.Order{}, &UnknownFieldsError{Fields: fields}
+			}
+			d.reportUnknown(topic, fields)
+		}
+	}
+
+	var o model.Order
+	err = json.Unmarshal(data, &o)
+	return o, err
+}
+
+func (d *JSONDecoder) reportUnknown(topic string, fields []string) {
+	if d.OnUnknown != nil {
+		d.OnUnknown(topic, fields)
+		return
+	}
+	log.Printf("Unknown fields in message from topic=%s: %s", topic, strings.Join(fields, ", "))
+	// Field names come from the payload, so only the topic is used as a key
+	// to keep the number of expvar keys bounded.
+	unknownFieldsVar.Add(topic, 1)
+}
+
+var Protobuf = DecoderFunc(func(ctx context.Context, data []byte, meta map[string]string) (model.Order, error) {
+	return orderpb.Unmarshal(data)
+})
+
+// Decoders picks a decoder by format name or by content type.
+type Decoders struct {
+	def      string
+	decoders map[string]Decoder
+	types    map[string]string
+}
+
+func NewDecoders(def string) *Decoders {
+	d := &Decoders{
+		def:      def,
+		decoders: make(map[string]Decoder),
+		types:    make(map[string]string),
+	}
+	d.Register(FormatJSON, JSON, "application/json")
+	d.Register(FormatProtobuf, Protobuf, "application/x-protobuf", "application/protobuf")
+	return d
+}
+
+// WithDefault returns a view of d that falls back to format when a message
+// has no content type. Registrations are shared with d.
+func (d *Decoders) WithDefault(format string) *Decoders {
+	return &Decoders{def: format, decoders: d.decoders, types: d.types}
+}
+
+func (d *Decoders) Register(format string, dec Decoder, contentTypes ...string) {
+	d.decoders[format] = dec
+	for _, ct := range contentTypes {
+		d.types[ct] = format
+	}
+}
+
+// Lookup resolves a format name or content type; an empty value resolves to
+// the default format.
+func (d *Decoders) Lookup(format string) (Decoder, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	if mt, _, _ := strings.Cut(format, ";"); mt != format {
+		format = strings.TrimSpace(mt)
+	}
+	if format == "" {
+		format = d.def
+	}
+	if f, ok := d.types[format]; ok {
+		format = f
+	}
+	dec, ok := d.decoders[format]
+	if !ok {
+		return nil, fmt.Errorf("no decoder for %q", format)
+	}
+	return dec, nil
+}
+
+// Decode picks the decoder from the content-type entry of meta.
+func (d *Decoders) Decode(ctx context.Context, data []byte, meta map[string]string) (model.Order, error) {
+	dec, err := d.Lookup(meta[ContentTypeHeader])
+	if err != nil {
+		return model.Order{}, err
+	}
+	return dec.Decode(ctx, data, meta)
+}
@@ -0,0 +1,182 @@
+package codec
+
+import (
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/orderpb"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/hamba/avro/v2"
+)
+
+func sampleOrder() model.Order {
+	return model.Order{
+		OrderUID:    "avro-1",
+		TrackNumber: "TRACK",
+		Entry:       "WBIL",
+		Delivery:    model.Delivery{Name: "Test Testov", Phone: "+9720000000", City: "Kiryat Mozkin", Email: "test@gmail.com"},
+		Payment:     model.Payment{Transaction: "avro-1", Currency: "USD", Amount: 1817, PaymentDT: 1637907727},
+		Items:       []model.Items{{ChrtID: 9934930, Name: "Mascaras", Brand: "Vivienne Sabo", Status: 202}},
+		CustomerID:  "test",
+		SmID:        99,
+		DateCreated: "2021-11-26T06:22:19Z",
+	}
+}
+
+// avroRecord converts an order to the generic form hamba/avro marshals, using
+// the same JSON field names the schema is written with.
+func avroRecord(t *testing.T, o model.Order) map[string]any {
+	t.Helper()
+
+	data, _ := json.Marshal(o)
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var m map[string]any
+	if err := dec.Decode(&m); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return toAvroValues(m).(map[string]any)
+}
+
+func toAvroValues(v any) any {
+	switch t := v.(type) {
+	case map[string]any:
+		for k, x := range t {
+			t[k] = toAvroValues(x)
+		}
+		return t
+	case []any:
+		for i, x := range t {
+			t[i] = toAvroValues(x)
+		}
+		return t
+	case json.Number:
+		n, _ := t.Int64()
+		return n
+	}
+	return v
+}
+
+func loadSchema(t *testing.T) string {
+	t.Helper()
+
+	data, err := os.ReadFile("order.avsc")
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	return string(data)
+}
+
+func TestAvro_DecodeConfluentWireFormat(t *testing.T) {
+	text := loadSchema(t)
+	schema := avro.MustParse(text)
+
+	want := sampleOrder()
+	payload, err := EncodeConfluent(schema, 42, avroRecord(t, want))
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+
+	dec := NewAvro(StaticRegistry{42: text})
+	got, err := dec.Decode(context.Background(), payload, nil)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.OrderUID != want.OrderUID || got.Payment.Amount != 1817 || got.Items[0].Status != 202 || got.SmID != 99 {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+}
+
+func TestAvro_UnknownSchemaID(t *testing.T) {
+	schema := avro.MustParse(loadSchema(t))
+	payload, _ := EncodeConfluent(schema, 7, avroRecord(t, sampleOrder()))
+
+	_, err := NewAvro(StaticRegistry{}).Decode(context.Background(), payload, nil)
+	if err == nil {
+		t.Fatalf("expected error for unknown schema id")
+	}
+}
+
+func TestAvro_RejectsPlainJSON(t *testing.T) {
+	_, err := NewAvro(StaticRegistry{}).Decode(context.Background(), []byte(`{"order_uid":"x"}`), nil)
+	if !errors.Is(err, ErrNotConfluentAvro) {
+		t.Fatalf("expected ErrNotConfluentAvro, got %v", err)
+	}
+}
+
+func TestRegistryClient_FetchesAndCaches(t *testing.T) {
+	text := loadSchema(t)
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/schemas/ids/42" {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"schema": text})
+	}))
+	defer srv.Close()
+
+	schema := avro.MustParse(text)
+	payload, _ := EncodeConfluent(schema, 42, avroRecord(t, sampleOrder()))
+
+	dec := NewAvro(NewRegistryClient(srv.URL + "/"))
+	for i := 0; i < 3; i++ {
+		if _, err := dec.Decode(context.Background(), payload, nil); err != nil {
+			t.Fatalf("decode %d: %v", i, err)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("expected schema fetched once, got %d requests", calls)
+	}
+}
+
+func TestRegistryClient_FailuresAreTemporary(t *testing.T) {
+	status := http.StatusServiceUnavailable
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	schema := avro.MustParse(loadSchema(t))
+	payload, _ := EncodeConfluent(schema, 42, avroRecord(t, sampleOrder()))
+	dec := NewAvro(NewRegistryClient(srv.URL))
+
+	_, err := dec.Decode(context.Background(), payload, nil)
+	var regErr *RegistryError
+	if !errors.As(err, &regErr) || !regErr.Temporary() {
+		t.Fatalf("expected temporary RegistryError, got %v", err)
+	}
+
+	status = http.StatusNotFound
+	_, err = dec.Decode(context.Background(), payload, nil)
+	if !errors.Is(err, ErrSchemaNotFound) || errors.As(err, &regErr) {
+		t.Fatalf("expected permanent ErrSchemaNotFound, got %v", err)
+	}
+}
+
+func TestDecoders_LookupByContentType(t *testing.T) {
+	d := NewDecoders(FormatJSON)
+	want := sampleOrder()
+
+	pb, err := d.Decode(context.Background(), orderpb.Marshal(want), map[string]string{ContentTypeHeader: "application/x-protobuf"})
+	if err != nil || pb.OrderUID != want.OrderUID {
+		t.Fatalf("protobuf decode: %v %+v", err, pb)
+	}
+
+	data, _ := json.Marshal(want)
+	js, err := d.Decode(context.Background(), data, nil)
+	if err != nil || js.OrderUID != want.OrderUID {
+		t.Fatalf("default json decode: %v %+v", err, js)
+	}
+
+	if _, err := d.Lookup("application/xml"); err == nil {
+		t.Fatalf("expected error for unknown content type")
+	}
+}
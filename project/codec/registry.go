@@ -0,0 +1,86 @@
+package codec
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrSchemaNotFound means the registry has no schema with the requested ID.
+// Other registry errors are treated as temporary.
+var ErrSchemaNotFound = errors.New("schema not found")
+
+type SchemaRegistry interface {
+	Schema(ctx context.Context, id int) (string, error)
+}
+
+// RegistryError is a failure to reach the schema registry. It is temporary:
+// the payload may decode fine once the registry is back.
+type RegistryError struct {
+	ID  int
+	Err error
+}
+
+func (e *RegistryError) Error() string { return fmt.Sprintf("fetch schema %d: %v", e.ID, e.Err) }
+
+func (e *RegistryError) Unwrap() error { return e.Err }
+
+func (e *RegistryError) Temporary() bool { return true }
+
+// StaticRegistry serves schemas from memory, for local setups and tests.
+type StaticRegistry map[int]string
+
+func (r StaticRegistry) Schema(ctx context.Context, id int) (string, error) {
+	s, ok := r[id]
+	if !ok {
+		return "", fmt.Errorf("%w: %d", ErrSchemaNotFound, id)
+	}
+	return s, nil
+}
+
+// RegistryClient talks to a Confluent compatible schema registry over HTTP.
+// Schemas are immutable per ID, so Avro caches them after the first lookup.
+type RegistryClient struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewRegistryClient(url string) *RegistryClient {
+	return &RegistryClient{
+		URL:    strings.TrimRight(url, "/"),
+		Client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (c *RegistryClient) Schema(ctx context.Context, id int) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/schemas/ids/%d", c.URL, id), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", fmt.Errorf("%w: %d", ErrSchemaNotFound, id)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("schema registry returned %s", resp.Status)
+	}
+
+	var body struct {
+		Schema string `json:"schema"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	return body.Schema, nil
+}
@@ -0,0 +1,105 @@
+package codec
+
+import (
+	"awesomeProject3/project/model"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type UnknownFieldsError struct {
+	Fields []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return "unknown fields: " + strings.Join(e.Fields, ", ")
+}
+
+var knownFieldsCache sync.Map
+
+func knownFields(t reflect.Type) map[string]reflect.Type {
+	if v, ok := knownFieldsCache.Load(t); ok {
+		return v.(map[string]reflect.Type)
+	}
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	knownFieldsCache.Store(t, fields)
+	return fields
+}
+
+// UnknownFields lists the JSON paths in data that model.Order does not
+// declare, e.g. "delivry" or "items[0].colour". Matching is case-insensitive
+// like encoding/json.
+func UnknownFields(data []byte) ([]string, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	var out []string
+	walkUnknown(doc, reflect.TypeOf(model.Order{}), "", &out)
+	sort.Strings(out)
+	return out, nil
+}
+
+func walkUnknown(v any, t reflect.Type, path string, out *[]string) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return
+		}
+		fields := knownFields(t)
+		for key, val := range obj {
+			ft, ok := fields[key]
+			if !ok {
+				ft, ok = foldLookup(fields, key)
+			}
+			p := key
+			if path != "" {
+				p = path + "." + key
+			}
+			if !ok {
+				*out = append(*out, p)
+				continue
+			}
+			walkUnknown(val, ft, p, out)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return
+		}
+		for i, el := range arr {
+			walkUnknown(el, t.Elem(), fmt.Sprintf("%s[%d]", path, i), out)
+		}
+	}
+}
+
+func foldLookup(fields map[string]reflect.Type, key string) (reflect.Type, bool) {
+	for name, t := range fields {
+		if strings.EqualFold(name, key) {
+			return t, true
+		}
+	}
+	return nil, false
+}
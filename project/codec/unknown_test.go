@@ -0,0 +1,87 @@
+package codec
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestUnknownFields_Paths(t *testing.T) {
+	data := []byte(`{"order_uid":"u-1","delivry":{},"Track_Number":"T","items":[{"name":"a"},{"colour":"red"}],"payment":{"amount":1,"tip":2}}`)
+
+	got, err := UnknownFields(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"delivry", "items[1].colour", "payment.tip"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestJSONDecoder_Strict_RejectsUnknownFields(t *testing.T) {
+	d := &JSONDecoder{Mode: JSONStrict}
+
+	_, err := d.Decode(context.Background(), []byte(`{"order_uid":"u-1","delivry":{}}`), nil)
+
+	var ufe *UnknownFieldsError
+	if !errors.As(err, &ufe) {
+		t.Fatalf("expected UnknownFieldsError, got %v", err)
+	}
+	if len(ufe.Fields) != 1 || ufe.Fields[0] != "delivry" {
+		t.Fatalf("expected [delivry], got %v", ufe.Fields)
+	}
+}
+
+func TestJSONDecoder_TopicModes(t *testing.T) {
+	var reported []string
+	d := &JSONDecoder{
+		Mode:       JSONStrict,
+		TopicModes: map[string]JSONMode{"legacy": JSONReport, "raw": JSONLenient},
+		OnUnknown:  func(topic string, fields []string) { reported = append(reported, topic+":"+fields[0]) },
+	}
+	data := []byte(`{"order_uid":"u-1","delivry":{}}`)
+
+	if o, err := d.Decode(context.Background(), data, map[string]string{"topic": "legacy"}); err != nil || o.OrderUID != "u-1" {
+		t.Fatalf("expected report mode to decode, got %v %+v", err, o)
+	}
+	if _, err := d.Decode(context.Background(), data, map[string]string{"topic": "raw"}); err != nil {
+		t.Fatalf("expected lenient mode to decode, got %v", err)
+	}
+	if _, err := d.Decode(context.Background(), data, map[string]string{"topic": "orders"}); err == nil {
+		t.Fatalf("expected default strict mode to reject")
+	}
+	if len(reported) != 1 || reported[0] != "legacy:delivry" {
+		t.Fatalf("expected one report for legacy topic, got %v", reported)
+	}
+}
+
+func TestJSONDecoder_ReportCountsPerTopic(t *testing.T) {
+	d := &JSONDecoder{Mode: JSONReport}
+	meta := map[string]string{"topic": "count-test"}
+
+	for _, data := range []string{`{"a1":1,"a2":2}`, `{"b1":1}`} {
+		if _, err := d.Decode(context.Background(), []byte(data), meta); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if got := unknownFieldsVar.Get("count-test"); got == nil || got.String() != "2" {
+		t.Fatalf("expected 2 messages counted for topic, got %v", got)
+	}
+	if unknownFieldsVar.Get("count-test:a1") != nil {
+		t.Fatalf("expected field names not to be used as keys")
+	}
+}
+
+func TestParseJSONMode(t *testing.T) {
+	if m, err := ParseJSONMode(""); err != nil || m != JSONLenient {
+		t.Fatalf("expected lenient by default, got %q %v", m, err)
+	}
+	if m, err := ParseJSONMode("Strict"); err != nil || m != JSONStrict {
+		t.Fatalf("expected strict, got %q %v", m, err)
+	}
+	if _, err := ParseJSONMode("loose"); err == nil {
+		t.Fatalf("expected error for unknown mode")
+	}
+}
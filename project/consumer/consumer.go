@@ -2,80 +2,137 @@ package consumer
 
 import (
 	"awesomeProject3/project/cache"
+	"awesomeProject3/project/codec"
 	"awesomeProject3/project/database"
 	"awesomeProject3/project/model"
+	"awesomeProject3/project/pipeline"
+	"awesomeProject3/project/redact"
 	"awesomeProject3/project/validation"
 	"context"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"log"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
 type DLQMessage struct {
-	Error     string          `json:"error"`
-	Payload   json.RawMessage `json:"payload"`
-	Topic     string          `json:"topic"`
-	Partition int             `json:"partition"`
-	Offset    int64           `json:"offset"`
-	Timestamp time.Time       `json:"timestamp"`
+	Error         string          `json:"error"`
+	UnknownFields []string        `json:"unknown_fields,omitempty"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	PayloadBase64 []byte          `json:"payload_base64,omitempty"`
+	Topic         string          `json:"topic"`
+	Partition     int             `json:"partition"`
+	Offset        int64           `json:"offset"`
+	Timestamp     time.Time       `json:"timestamp"`
 }
 
 type CS interface {
-	Start(ctx context.Context, broker string, topic string, group string)
+	Start(ctx context.Context, cfg kafka.ReaderConfig)
 }
 
 type Consumer struct {
 	DB         database.DB
+	Decoders   *codec.Decoders
+	RedactDLQ  bool
+	Topics     []TopicConfig
 	cache      cache.CC
 	dlqWriter  *kafka.Writer
 	validateFn func(*model.Order) error
+	pipeline   *pipeline.Pipeline
 }
 
 func (c *Consumer) sendToDLQ(ctx context.Context, msg kafka.Message, procErr error) {
-	if c.dlqWriter == nil {
+	w := c.dlq(msg.Topic)
+	if w == nil {
 		log.Printf("DLQ writer is nil, can't send message to DLQ: %v", procErr)
 		return
 	}
 
+	data, err := json.Marshal(newDLQMessage(msg, procErr, c.RedactDLQ))
+	if err != nil {
+		log.Printf("Can't marshal DLQ message: %v", err)
+		return
+	}
+
+	if err := w.WriteMessages(ctx, kafka.Message{
+		Key:   msg.Key,
+		Value: data,
+	}); err != nil {
+		log.Printf("Can't write message to DLQ: %v", err)
+	}
+}
+
+func newDLQMessage(msg kafka.Message, procErr error, redactPayload bool) DLQMessage {
 	dlq := DLQMessage{
 		Error:     procErr.Error(),
-		Payload:   msg.Value,
 		Topic:     msg.Topic,
 		Partition: msg.Partition,
 		Offset:    msg.Offset,
 		Timestamp: time.Now(),
 	}
 
-	data, err := json.Marshal(dlq)
-	if err != nil {
-		log.Printf("Can't marshal DLQ message: %v", err)
-		return
+	// Unknown fields often explain a validation error, e.g. a typo in
+	// "delivery" shows up as a missing required field.
+	var ufe *codec.UnknownFieldsError
+	if errors.As(procErr, &ufe) {
+		dlq.UnknownFields = ufe.Fields
+	} else if fields, err := codec.UnknownFields(msg.Value); err == nil {
+		dlq.UnknownFields = fields
 	}
 
-	if err := c.dlqWriter.WriteMessages(ctx, kafka.Message{
-		Key:   msg.Key,
-		Value: data,
-	}); err != nil {
-		log.Printf("Can't write message to DLQ: %v", err)
+	switch {
+	case json.Valid(msg.Value) && redactPayload:
+		if masked, err := redact.JSON(msg.Value, reflect.TypeOf(model.Order{})); err == nil {
+			dlq.Payload = masked
+		}
+	case json.Valid(msg.Value):
+		dlq.Payload = msg.Value
+	case !redactPayload:
+		// Binary payloads can't be masked, so they are dropped when redacting.
+		dlq.PayloadBase64 = msg.Value
 	}
+
+	return dlq
 }
 
 func NewConsumer(db database.DB, cache cache.CC, dlqwritrer *kafka.Writer) *Consumer {
-	return &Consumer{DB: db, cache: cache, dlqWriter: dlqwritrer, validateFn: validation.ValidateOrder}
+	c := &Consumer{
+		DB:         db,
+		Decoders:   codec.NewDecoders(codec.FormatJSON),
+		cache:      cache,
+		dlqWriter:  dlqwritrer,
+		validateFn: validation.ValidateOrder,
+	}
+	c.pipeline = pipeline.New(
+		pipeline.Decode(func(ctx context.Context, env *pipeline.Envelope) (model.Order, error) {
+			return c.decoders(env.Meta["topic"]).Decode(ctx, env.Raw, env.Meta)
+		}),
+		pipeline.Func("validate", func(ctx context.Context, env *pipeline.Envelope) error {
+			validate := c.validator(env.Meta["topic"])
+			if validate == nil {
+				return nil
+			}
+			return pipeline.Reject(validate(&env.Order))
+		}),
+		pipeline.Enrich(func(ctx context.Context, env *pipeline.Envelope) error {
+			env.Order.SourceTopic = env.Meta["topic"]
+			return nil
+		}),
+		pipeline.Persist(db),
+		pipeline.Cache(cache),
+	)
+	return c
 }
 
-func (c *Consumer) Start(ctx context.Context, broker, topic, group string) {
-	r := kafka.NewReader(kafka.ReaderConfig{
-		Brokers: []string{broker},
-		Topic:   topic,
-		GroupID: group,
-	})
+func (c *Consumer) Start(ctx context.Context, cfg kafka.ReaderConfig) {
+	r := kafka.NewReader(cfg)
 	defer r.Close()
 
-	log.Printf("Consumer starting listening on: topic=%s group=%s", topic, group)
+	log.Printf("Consumer starting listening on: brokers=%v topic=%s group=%s", cfg.Brokers, cfg.Topic, cfg.GroupID)
 
 	for {
 		msg, err := r.FetchMessage(ctx)
@@ -101,29 +158,36 @@ func (c *Consumer) Start(ctx context.Context, broker, topic, group string) {
 }
 
 func (c *Consumer) HandleMessage(ctx context.Context, msg kafka.Message) bool {
-	var order model.Order
-
-	if err := json.Unmarshal(msg.Value, &order); err != nil {
-		log.Printf("Can't unmarshal json: %v", err)
-		c.sendToDLQ(ctx, msg, fmt.Errorf("unmarshal: %w", err))
-		return true
+	meta := make(map[string]string, len(msg.Headers)+1)
+	for _, h := range msg.Headers {
+		meta[strings.ToLower(h.Key)] = string(h.Value)
+	}
+	// The topic picks validation, decoders and the DLQ, so a producer must
+	// not be able to set it with a header.
+	meta["topic"] = msg.Topic
+
+	env := &pipeline.Envelope{
+		Raw:    msg.Value,
+		Source: pipeline.SourceKafka,
+		Meta:   meta,
 	}
 
-	if c.validateFn != nil {
-		if err := c.validateFn(&order); err != nil {
-			log.Printf("Invalid order data: %v", err)
-			c.sendToDLQ(ctx, msg, fmt.Errorf("validation: %w", err))
+	if err := c.pipeline.Run(ctx, env); err != nil {
+		if errors.Is(err, database.ErrExists) {
+			// A redelivery or a reused order_uid: the stored order wins and
+			// the cache is left alone.
+			log.Printf("Order with id=%s already exists, skipped insert", env.Order.OrderUID)
 			return true
 		}
-	}
-
-	if err := c.DB.InsertOrder(order); err != nil {
-		log.Printf("Can't insert order: %v", err)
+		if pipeline.IsRejected(err) {
+			log.Printf("Rejected message: %v", err)
+			c.sendToDLQ(ctx, msg, err)
+			return true
+		}
+		log.Printf("Can't process order: %v", err)
 		return false
 	}
 
-	c.cache.Set(order.OrderUID, order)
-
-	log.Printf("Order processed: %s", order.OrderUID)
+	log.Printf("Order processed: %s", env.Order.OrderUID)
 	return true
 }
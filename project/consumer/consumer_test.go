@@ -4,9 +4,11 @@ import (
 	"awesomeProject3/project/cache"
 	"awesomeProject3/project/database"
 	"awesomeProject3/project/model"
+	"awesomeProject3/project/orderpb"
 	"context"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/segmentio/kafka-go"
@@ -91,6 +93,27 @@ func TestHandleMessage_DBError_DoesNotCommit_NoCacheSet(t *testing.T) {
 	}
 }
 
+func TestHandleMessage_Duplicate_Commits_NoCacheSet(t *testing.T) {
+	db := &database.MockDB{}
+	ca := &cache.MockCache{}
+
+	cons := NewConsumer(db, ca, nil)
+	cons.validateFn = func(o *model.Order) error { return nil }
+
+	db.InsertOrderFunc = func(order model.Order) error {
+		return database.ErrExists
+	}
+
+	msg := kafka.Message{Value: []byte(`{"order_uid":"order-777"}`)}
+
+	if !cons.HandleMessage(context.Background(), msg) {
+		t.Fatalf("expected commit=true for an already stored order")
+	}
+	if ca.SetCalls != 0 {
+		t.Fatalf("expected cache.Set not called for a duplicate, got %d", ca.SetCalls)
+	}
+}
+
 func TestHandleMessage_Success_Commits_InsertsAndCaches(t *testing.T) {
 	db := &database.MockDB{}
 	ca := &cache.MockCache{}
@@ -120,3 +143,112 @@ func TestHandleMessage_Success_Commits_InsertsAndCaches(t *testing.T) {
 		t.Fatalf("expected cache.Set uid=ok-1, got %q", ca.LastSetUID)
 	}
 }
+
+func TestHandleMessage_ProtobufByHeader(t *testing.T) {
+	db := &database.MockDB{}
+	ca := &cache.MockCache{}
+
+	cons := NewConsumer(db, ca, nil)
+	cons.validateFn = func(o *model.Order) error { return nil }
+
+	msg := kafka.Message{
+		Value:   orderpb.Marshal(model.Order{OrderUID: "pb-1"}),
+		Headers: []kafka.Header{{Key: "Content-Type", Value: []byte("application/x-protobuf")}},
+	}
+
+	commit := cons.HandleMessage(context.Background(), msg)
+
+	if !commit {
+		t.Fatalf("expected commit=true on success")
+	}
+	if db.LastInsert.OrderUID != "pb-1" {
+		t.Fatalf("expected protobuf order inserted, got %q", db.LastInsert.OrderUID)
+	}
+}
+
+func TestHandleMessage_UnknownContentType_Commits_NoDB(t *testing.T) {
+	db := &database.MockDB{}
+	ca := &cache.MockCache{}
+
+	cons := NewConsumer(db, ca, nil)
+
+	msg := kafka.Message{
+		Value:   []byte(`{"order_uid":"x"}`),
+		Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/xml")}},
+	}
+
+	commit := cons.HandleMessage(context.Background(), msg)
+
+	if !commit {
+		t.Fatalf("expected commit=true for undecodable message")
+	}
+	if db.InsertCalls != 0 {
+		t.Fatalf("expected db.InsertOrder not called, got %d", db.InsertCalls)
+	}
+}
+
+func TestHandleMessage_UpcastsV1Payload(t *testing.T) {
+	db := &database.MockDB{}
+	ca := &cache.MockCache{}
+
+	cons := NewConsumer(db, ca, nil)
+	cons.validateFn = func(o *model.Order) error { return nil }
+
+	msg := kafka.Message{
+		Value:   []byte(`{"order_uid":"v1-1","sm_id":99,"payment":{"amount":1817}}`),
+		Headers: []kafka.Header{{Key: "schema_version", Value: []byte("1")}},
+	}
+
+	commit := cons.HandleMessage(context.Background(), msg)
+
+	if !commit {
+		t.Fatalf("expected commit=true on success")
+	}
+	if db.LastInsert.SmID != 99 || db.LastInsert.Payment.Amount != 1817 {
+		t.Fatalf("expected v1 payload to be decoded, got %+v", db.LastInsert)
+	}
+	if db.LastInsert.SchemaVersion != 2 {
+		t.Fatalf("expected schema_version 2 after upcast, got %d", db.LastInsert.SchemaVersion)
+	}
+}
+
+func TestNewDLQMessage_ListsUnknownFields(t *testing.T) {
+	msg := kafka.Message{Topic: "orders", Value: []byte(`{"order_uid":"u-1","delivry":{}}`)}
+
+	dlq := newDLQMessage(msg, errors.New("delivery is required"), false)
+
+	if len(dlq.UnknownFields) != 1 || dlq.UnknownFields[0] != "delivry" {
+		t.Fatalf("expected unknown field delivry, got %v", dlq.UnknownFields)
+	}
+	if string(dlq.Payload) != string(msg.Value) {
+		t.Fatalf("expected json payload to be kept, got %s", dlq.Payload)
+	}
+}
+
+func TestNewDLQMessage_BinaryPayload(t *testing.T) {
+	msg := kafka.Message{Value: orderpb.Marshal(model.Order{OrderUID: "pb-1"})}
+
+	dlq := newDLQMessage(msg, errors.New("bad"), false)
+
+	if dlq.Payload != nil || len(dlq.PayloadBase64) == 0 {
+		t.Fatalf("expected binary payload in payload_base64, got %+v", dlq)
+	}
+}
+
+func TestNewDLQMessage_RedactsPayload(t *testing.T) {
+	msg := kafka.Message{Value: []byte(`{"order_uid":"u-1","delivery":{"phone":"+79161234512","email":"test@gmail.com"}}`)}
+
+	dlq := newDLQMessage(msg, errors.New("bad"), true)
+
+	if strings.Contains(string(dlq.Payload), "+79161234512") || strings.Contains(string(dlq.Payload), "test@gmail.com") {
+		t.Fatalf("expected contacts to be masked, got %s", dlq.Payload)
+	}
+	if !strings.Contains(string(dlq.Payload), `"order_uid":"u-1"`) {
+		t.Fatalf("expected other fields to be kept, got %s", dlq.Payload)
+	}
+
+	binary := newDLQMessage(kafka.Message{Value: orderpb.Marshal(model.Order{OrderUID: "pb-1"})}, errors.New("bad"), true)
+	if binary.PayloadBase64 != nil {
+		t.Fatalf("expected binary payload to be dropped when redacting")
+	}
+}
@@ -0,0 +1,180 @@
+package consumer
+
+import (
+	"awesomeProject3/project/codec"
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/validation"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TopicConfig overrides the consumer defaults for the topics it matches,
+// either by exact name or by Pattern. Nil fields keep the defaults.
+type TopicConfig struct {
+	Topic    string
+	Pattern  *regexp.Regexp
+	Decoders *codec.Decoders
+	Validate func(*model.Order) error
+	DLQ      *kafka.Writer
+}
+
+func (t TopicConfig) matches(topic string) bool {
+	if t.Pattern != nil {
+		return t.Pattern.MatchString(topic)
+	}
+	return t.Topic == topic
+}
+
+// TopicSettings is an entry of the topics file, e.g.
+// {"pattern": "^orders-.*$", "format": "avro", "validation": "minimal", "dlq": "orders_dlq_regional"}.
+type TopicSettings struct {
+	Topic      string `json:"topic"`
+	Pattern    string `json:"pattern"`
+	Format     string `json:"format"`
+	Validation string `json:"validation"`
+	DLQ        string `json:"dlq"`
+}
+
+func LoadTopicSettings(path string) ([]TopicSettings, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var settings []TopicSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	for _, s := range settings {
+		if (s.Topic == "") == (s.Pattern == "") {
+			return nil, fmt.Errorf("topic settings need exactly one of topic or pattern: %+v", s)
+		}
+	}
+	return settings, nil
+}
+
+// NewTopicConfig resolves s against the consumer's decoders. dlq may be nil
+// to use the default DLQ.
+func NewTopicConfig(s TopicSettings, decoders *codec.Decoders, dlq *kafka.Writer) (TopicConfig, error) {
+	t := TopicConfig{Topic: s.Topic, DLQ: dlq}
+	if s.Pattern != "" {
+		re, err := regexp.Compile(s.Pattern)
+		if err != nil {
+			return t, err
+		}
+		t.Pattern = re
+	}
+	if s.Format != "" {
+		t.Decoders = decoders.WithDefault(s.Format)
+		if _, err := t.Decoders.Lookup(""); err != nil {
+			return t, err
+		}
+	}
+	if s.Validation != "" {
+		fn, err := validation.Profile(s.Validation)
+		if err != nil {
+			return t, err
+		}
+		t.Validate = fn
+	}
+	return t, nil
+}
+
+// topicConfig returns the first entry of Topics matching topic.
+func (c *Consumer) topicConfig(topic string) TopicConfig {
+	for _, t := range c.Topics {
+		if t.matches(topic) {
+			return t
+		}
+	}
+	return TopicConfig{}
+}
+
+func (c *Consumer) decoders(topic string) *codec.Decoders {
+	if t := c.topicConfig(topic); t.Decoders != nil {
+		return t.Decoders
+	}
+	return c.Decoders
+}
+
+func (c *Consumer) validator(topic string) func(*model.Order) error {
+	if t := c.topicConfig(topic); t.Validate != nil {
+		return t.Validate
+	}
+	return c.validateFn
+}
+
+func (c *Consumer) dlq(topic string) *kafka.Writer {
+	if t := c.topicConfig(topic); t.DLQ != nil {
+		return t.DLQ
+	}
+	return c.dlqWriter
+}
+
+// StartPattern consumes every topic matching pattern. listTopics is polled
+// every refresh interval and the reader is restarted when the set of
+// matching topics changes.
+func (c *Consumer) StartPattern(ctx context.Context, cfg kafka.ReaderConfig, pattern *regexp.Regexp,
+	listTopics func(ctx context.Context) ([]string, error), refresh time.Duration) {
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	var (
+		current []string
+		stop    context.CancelFunc
+		done    chan struct{}
+	)
+	for {
+		topics, err := listTopics(ctx)
+		if err != nil {
+			log.Printf("Can't list topics: %v", err)
+		} else if topics = matchTopics(topics, pattern); !slices.Equal(topics, current) {
+			if stop != nil {
+				stop()
+				<-done
+			}
+			current, stop, done = topics, nil, nil
+			if len(topics) > 0 {
+				log.Printf("Subscribing to topics %v matching %s", topics, pattern)
+				readerCtx, cancel := context.WithCancel(ctx)
+				stop, done = cancel, make(chan struct{})
+				rc := cfg
+				rc.Topic, rc.GroupTopics = "", topics
+				go func() {
+					defer close(done)
+					c.Start(readerCtx, rc)
+				}()
+			} else {
+				log.Printf("No topics match %s", pattern)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if done != nil {
+				<-done
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func matchTopics(topics []string, pattern *regexp.Regexp) []string {
+	var out []string
+	for _, t := range topics {
+		if pattern.MatchString(t) {
+			out = append(out, t)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,124 @@
+package consumer
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/codec"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/orderpb"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestHandleMessage_TagsSourceTopic(t *testing.T) {
+	db := &database.MockDB{}
+	cons := NewConsumer(db, &cache.MockCache{}, nil)
+	cons.validateFn = func(o *model.Order) error { return nil }
+
+	msg := kafka.Message{Topic: "orders-ru", Value: []byte(`{"order_uid":"ru-1"}`)}
+
+	if !cons.HandleMessage(context.Background(), msg) {
+		t.Fatalf("expected commit=true on success")
+	}
+	if db.LastInsert.SourceTopic != "orders-ru" {
+		t.Fatalf("expected source topic orders-ru, got %q", db.LastInsert.SourceTopic)
+	}
+}
+
+func TestHandleMessage_PerTopicSettings(t *testing.T) {
+	db := &database.MockDB{}
+	cons := NewConsumer(db, &cache.MockCache{}, nil)
+	cons.validateFn = func(o *model.Order) error { return errors.New("bad order") }
+
+	kz, err := NewTopicConfig(TopicSettings{Pattern: "^orders-kz", Format: codec.FormatProtobuf, Validation: "none"}, cons.Decoders, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cons.Topics = []TopicConfig{kz}
+
+	msg := kafka.Message{Topic: "orders-kz-1", Value: orderpb.Marshal(model.Order{OrderUID: "kz-1"})}
+	if !cons.HandleMessage(context.Background(), msg) || db.InsertCalls != 1 {
+		t.Fatalf("expected protobuf order to be stored without validation, got %d inserts", db.InsertCalls)
+	}
+	if db.LastInsert.OrderUID != "kz-1" {
+		t.Fatalf("expected order kz-1, got %q", db.LastInsert.OrderUID)
+	}
+
+	msg = kafka.Message{Topic: "orders-ru", Value: []byte(`{"order_uid":"ru-1"}`)}
+	cons.HandleMessage(context.Background(), msg)
+	if db.InsertCalls != 1 {
+		t.Fatalf("expected other topics to keep default validation, got %d inserts", db.InsertCalls)
+	}
+}
+
+func TestHandleMessage_IgnoresTopicHeader(t *testing.T) {
+	db := &database.MockDB{}
+	cons := NewConsumer(db, &cache.MockCache{}, nil)
+	cons.validateFn = func(o *model.Order) error { return errors.New("bad order") }
+
+	trusted, err := NewTopicConfig(TopicSettings{Topic: "orders-trusted", Validation: "none"}, cons.Decoders, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cons.Topics = []TopicConfig{trusted}
+
+	msg := kafka.Message{
+		Topic:   "orders",
+		Value:   []byte(`{"order_uid":"x"}`),
+		Headers: []kafka.Header{{Key: "Topic", Value: []byte("orders-trusted")}},
+	}
+	cons.HandleMessage(context.Background(), msg)
+	if db.InsertCalls != 0 {
+		t.Fatalf("expected topic header to be ignored and the order rejected, got %d inserts", db.InsertCalls)
+	}
+
+	cons.validateFn = func(o *model.Order) error { return nil }
+	cons.HandleMessage(context.Background(), msg)
+	if db.LastInsert.SourceTopic != "orders" {
+		t.Fatalf("expected source topic orders, got %q", db.LastInsert.SourceTopic)
+	}
+}
+
+func TestNewTopicConfig_Invalid(t *testing.T) {
+	decoders := codec.NewDecoders(codec.FormatJSON)
+	for _, s := range []TopicSettings{
+		{Topic: "a", Format: "xml"},
+		{Topic: "a", Validation: "paranoid"},
+		{Pattern: "("},
+	} {
+		if _, err := NewTopicConfig(s, decoders, nil); err == nil {
+			t.Fatalf("expected error for %+v", s)
+		}
+	}
+}
+
+func TestLoadTopicSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "topics.json")
+	_ = os.WriteFile(path, []byte(`[{"topic":"orders-ru","dlq":"orders-ru-dlq"},{"pattern":"^orders-"}]`), 0o600)
+
+	settings, err := LoadTopicSettings(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(settings) != 2 || settings[0].DLQ != "orders-ru-dlq" {
+		t.Fatalf("expected two entries, got %+v", settings)
+	}
+
+	_ = os.WriteFile(path, []byte(`[{"topic":"a","pattern":"b"}]`), 0o600)
+	if _, err := LoadTopicSettings(path); err == nil {
+		t.Fatalf("expected error for entry with both topic and pattern")
+	}
+}
+
+func TestMatchTopics(t *testing.T) {
+	got := matchTopics([]string{"orders-kz", "payments", "orders-ru"}, regexp.MustCompile(`^orders-`))
+	if len(got) != 2 || got[0] != "orders-kz" || got[1] != "orders-ru" {
+		t.Fatalf("expected sorted matching topics, got %v", got)
+	}
+}
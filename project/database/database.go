@@ -1,32 +1,90 @@
 package database
 
 import (
+	"awesomeProject3/project/keyring"
 	"awesomeProject3/project/model"
+	"awesomeProject3/project/schema"
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type DB interface {
 	InsertOrder(o model.Order) error
+	InsertOrders(orders []model.Order) (int, error)
 	GetOrder(id string) (model.Order, error)
 	GetAllOrders() ([]model.Order, error)
+	GetOrderByTrack(track string) (model.Order, error)
+	FindOrders(f OrderFilter) ([]model.Order, error)
+	SearchOrders(query string, limit, offset int) ([]SearchResult, int, error)
+	GetCustomerOrders(customerID string, limit, offset int) (CustomerOrders, error)
+	StreamOrders(ctx context.Context, f ExportFilter, fn func(model.Order) error) error
+	RelayOutbox(ctx context.Context, limit int, publish func([]OutboxEvent) error) (int, error)
+	CleanupOutbox(ctx context.Context, sentBefore time.Time) (int64, error)
 	Close()
 }
 
+// ExportFilter selects orders by created_at, the time the row was stored,
+// rather than the producer's date_created, which is not validated as a
+// timestamp.
+type ExportFilter struct {
+	From       time.Time
+	To         time.Time
+	CustomerID string
+}
+
+type SearchResult struct {
+	Order model.Order `json:"order"`
+	Rank  float32     `json:"rank"`
+}
+
+// CustomerOrders is one page of a customer's orders, newest first. Count and
+// Totals cover all of the customer's orders.
+type CustomerOrders struct {
+	Orders []model.Order
+	Count  int
+	// Totals sums payment.amount per currency.
+	Totals map[string]int64
+}
+
+type OrderFilter struct {
+	Transaction string
+	Email       string
+	Phone       string
+}
+
+func (f OrderFilter) Empty() bool {
+	return f.Transaction == "" && f.Email == "" && f.Phone == ""
+}
+
+const findOrdersLimit = 100
+
 type Database struct {
-	Pool *pgxpool.Pool
+	Pool    *pgxpool.Pool
+	Keyring *keyring.Keyring
 }
 
-func NewDB(connection string) (DB, error) {
+type Option func(*Database)
+
+// WithKeyring enables encryption of sensitive fields at rest.
+func WithKeyring(k *keyring.Keyring) Option {
+	return func(db *Database) { db.Keyring = k }
+}
+
+func NewDB(connection string, opts ...Option) (DB, error) {
 	pool, err := pgxpool.New(context.Background(), connection)
 	if err != nil {
 		return nil, err
 	}
 	db := &Database{Pool: pool}
+	for _, opt := range opts {
+		opt(db)
+	}
 	_, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
@@ -35,11 +93,38 @@ func NewDB(connection string) (DB, error) {
 
 func (db *Database) Close() { db.Pool.Close() }
 
+func encodeOrder(o model.Order) ([]byte, error) {
+	o.SchemaVersion = schema.CurrentVersion
+	return json.Marshal(o)
+}
+
+// decodeOrder upcasts rows written by older versions of the service before
+// unmarshalling them.
+func decodeOrder(data []byte) (model.Order, error) {
+	data, err := schema.Upcast(data, 0)
+	if err != nil {
+		return model.Order{}, err
+	}
+	var o model.Order
+	if err := json.Unmarshal(data, &o); err != nil {
+		return model.Order{}, err
+	}
+	return o, nil
+}
+
+func decodeOrderRow(data []byte, createdAt *time.Time) (model.Order, error) {
+	o, err := decodeOrder(data)
+	if err == nil && createdAt != nil {
+		o.CreatedAt = *createdAt
+	}
+	return o, err
+}
+
 func (db *Database) InsertOrder(o model.Order) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	jsonData, err := json.Marshal(o)
+	args, err := db.insertArgs(o)
 	if err != nil {
 		return err
 	}
@@ -49,45 +134,66 @@ func (db *Database) InsertOrder(o model.Order) error {
 	}
 	defer tx.Rollback(ctx)
 
-	cmdTag, err := tx.Exec(ctx,
-		"INSERT INTO orders (order_uid, data) VALUES ($1, $2) ON CONFLICT DO NOTHING",
-		o.OrderUID, jsonData,
-	)
+	cmdTag, err := tx.Exec(ctx, insertOrderSQL, args...)
 	if err != nil {
 		return err
 	}
 	if cmdTag.RowsAffected() == 0 {
-		log.Printf("Order with id=%s already exists, skipped insert", o.OrderUID)
+		return ErrExists
 	}
 
 	return tx.Commit(ctx)
 }
 
-func (db *Database) GetOrder(id string) (model.Order, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+func (db *Database) InsertOrders(orders []model.Order) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	var jsonData []byte
-
-	row := db.Pool.QueryRow(ctx, "SELECT data FROM orders WHERE order_uid = $1", id)
-	if err := row.Scan(&jsonData); err != nil {
-		return model.Order{}, err
+	batch := &pgx.Batch{}
+	for _, o := range orders {
+		args, err := db.insertArgs(o)
+		if err != nil {
+			return 0, err
+		}
+		batch.Queue(insertOrderSQL, args...)
 	}
 
-	var o model.Order
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
 
-	if err := json.Unmarshal(jsonData, &o); err != nil {
-		return model.Order{}, err
+	br := tx.SendBatch(ctx, batch)
+	inserted := 0
+	for range orders {
+		cmdTag, err := br.Exec()
+		if err != nil {
+			br.Close()
+			return 0, err
+		}
+		inserted += int(cmdTag.RowsAffected())
+	}
+	if err := br.Close(); err != nil {
+		return 0, err
 	}
 
-	return o, nil
+	return inserted, tx.Commit(ctx)
+}
+
+func (db *Database) GetOrder(id string) (model.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := db.Pool.QueryRow(ctx, "SELECT "+orderColumns+" FROM orders WHERE order_uid = $1", id)
+	return db.scanOrder(row)
 }
 
 func (db *Database) GetAllOrders() ([]model.Order, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := db.Pool.Query(ctx, "SELECT data FROM orders")
+	rows, err := db.Pool.Query(ctx, "SELECT "+orderColumns+" FROM orders")
 	if err != nil {
 		return nil, err
 	}
@@ -96,17 +202,80 @@ func (db *Database) GetAllOrders() ([]model.Order, error) {
 	var orders []model.Order
 
 	for rows.Next() {
-		var jsonData []byte
-		if err := rows.Scan(&jsonData); err != nil {
+		o, err := db.scanOrder(rows)
+		if err != nil {
 			return nil, err
 		}
-		var o model.Order
+		orders = append(orders, o)
+
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+func (db *Database) GetOrderByTrack(track string) (model.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	row := db.Pool.QueryRow(ctx,
+		"SELECT "+orderColumns+" FROM orders WHERE data->>'track_number' = $1 ORDER BY created_at DESC LIMIT 1",
+		track,
+	)
+	return db.scanOrder(row)
+}
+
+func (db *Database) FindOrders(f OrderFilter) ([]model.Order, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var (
+		conds []string
+		args  []any
+	)
+	add := func(expr, bidx, value string) {
+		if value == "" {
+			return
+		}
+		args = append(args, value)
+		cond := fmt.Sprintf("%s = $%d", expr, len(args))
+		// Encrypted rows have the field blanked and are matched by blind
+		// index; rows stored in plaintext still match the JSON field.
+		if db.Keyring != nil {
+			args = append(args, db.Keyring.BlindIndex(bidx, value))
+			cond = fmt.Sprintf("(%s OR %s_bidx = $%d)", cond, bidx, len(args))
+		}
+		conds = append(conds, cond)
+	}
+	add("data->'payment'->>'transaction'", bidxTransaction, f.Transaction)
+	add("data->'delivery'->>'email'", bidxEmail, f.Email)
+	add("data->'delivery'->>'phone'", bidxPhone, f.Phone)
+
+	if len(conds) == 0 {
+		return nil, fmt.Errorf("empty order filter")
+	}
+
+	query := fmt.Sprintf(
+		"SELECT "+orderColumns+" FROM orders WHERE %s ORDER BY created_at DESC LIMIT %d",
+		strings.Join(conds, " AND "), findOrdersLimit,
+	)
 
-		if err := json.Unmarshal(jsonData, &o); err != nil {
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orders := []model.Order{}
+
+	for rows.Next() {
+		o, err := db.scanOrder(rows)
+		if err != nil {
 			return nil, err
 		}
 		orders = append(orders, o)
-
 	}
 	if err := rows.Err(); err != nil {
 		return nil, err
@@ -114,3 +283,162 @@ func (db *Database) GetAllOrders() ([]model.Order, error) {
 
 	return orders, nil
 }
+
+func (db *Database) SearchOrders(query string, limit, offset int) ([]SearchResult, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	words, idx := db.searchQueryArgs(query)
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+orderColumns+`, ts_rank(search, q) AS rank, count(*) OVER () AS total
+		FROM orders, orders_search_query(websearch_to_tsquery('simple', $1), $4::text[], $5::text[]) AS q
+		WHERE search @@ q
+		ORDER BY rank DESC, created_at DESC
+		LIMIT $2 OFFSET $3`,
+		query, limit, offset, words, idx,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	total := 0
+
+	for rows.Next() {
+		var res SearchResult
+		o, err := db.scanOrder(rows, &res.Rank, &total)
+		if err != nil {
+			return nil, 0, err
+		}
+		res.Order = o
+		results = append(results, res)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	// The window count comes with the rows, so a page past the end needs
+	// its own count.
+	if len(results) == 0 && offset > 0 {
+		err := db.Pool.QueryRow(ctx,
+			"SELECT count(*) FROM orders"+
+				" WHERE search @@ orders_search_query(websearch_to_tsquery('simple', $1), $2::text[], $3::text[])",
+			query, words, idx,
+		).Scan(&total)
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return results, total, nil
+}
+
+func (db *Database) GetCustomerOrders(customerID string, limit, offset int) (CustomerOrders, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// One snapshot for both queries, so the totals match the page.
+	tx, err := db.Pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return CustomerOrders{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	res := CustomerOrders{Orders: []model.Order{}, Totals: make(map[string]int64)}
+
+	rows, err := tx.Query(ctx, `
+		SELECT coalesce(data->'payment'->>'currency', ''), count(*),
+			coalesce(sum((data->'payment'->>'amount')::bigint), 0)
+		FROM orders WHERE data->>'customer_id' = $1
+		GROUP BY 1`,
+		customerID,
+	)
+	if err != nil {
+		return CustomerOrders{}, err
+	}
+	for rows.Next() {
+		var (
+			currency string
+			count    int
+			amount   int64
+		)
+		if err := rows.Scan(&currency, &count, &amount); err != nil {
+			rows.Close()
+			return CustomerOrders{}, err
+		}
+		res.Count += count
+		res.Totals[currency] = amount
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return CustomerOrders{}, err
+	}
+
+	rows, err = tx.Query(ctx,
+		"SELECT "+orderColumns+" FROM orders WHERE data->>'customer_id' = $1"+
+			" ORDER BY data->>'date_created' DESC, order_uid LIMIT $2 OFFSET $3",
+		customerID, limit, offset,
+	)
+	if err != nil {
+		return CustomerOrders{}, err
+	}
+	for rows.Next() {
+		o, err := db.scanOrder(rows)
+		if err != nil {
+			rows.Close()
+			return CustomerOrders{}, err
+		}
+		res.Orders = append(res.Orders, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return CustomerOrders{}, err
+	}
+
+	return res, tx.Commit(ctx)
+}
+
+func (db *Database) StreamOrders(ctx context.Context, f ExportFilter, fn func(model.Order) error) error {
+	var (
+		conds []string
+		args  []any
+	)
+	add := func(cond string, value any) {
+		args = append(args, value)
+		conds = append(conds, fmt.Sprintf(cond, len(args)))
+	}
+	if !f.From.IsZero() {
+		add("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at < $%d", f.To)
+	}
+	if f.CustomerID != "" {
+		add("data->>'customer_id' = $%d", f.CustomerID)
+	}
+
+	query := "SELECT " + orderColumns + " FROM orders"
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+	query += " ORDER BY created_at, order_uid"
+
+	rows, err := db.Pool.Query(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o, err := db.scanOrder(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(o); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
@@ -2,24 +2,46 @@ package database
 
 import (
 	"awesomeProject3/project/model"
+	"context"
 	"sync"
+	"time"
 )
 
 type MockDB struct {
 	mu sync.Mutex
 
 	InsertOrderFunc  func(order model.Order) error
+	InsertOrdersFunc func(orders []model.Order) (int, error)
 	GetOrderFunc     func(id string) (model.Order, error)
 	GetAllOrdersFunc func() ([]model.Order, error)
+	GetByTrackFunc   func(track string) (model.Order, error)
+	FindOrdersFunc   func(f OrderFilter) ([]model.Order, error)
+	SearchFunc       func(query string, limit, offset int) ([]SearchResult, int, error)
+	GetCustomerFunc  func(customerID string, limit, offset int) (CustomerOrders, error)
+	StreamOrdersFunc func(f ExportFilter, fn func(model.Order) error) error
+	RelayOutboxFunc  func(limit int, publish func([]OutboxEvent) error) (int, error)
+	CleanupFunc      func(sentBefore time.Time) (int64, error)
 	CloseFunc        func()
 
-	InsertCalls int
-	GetCalls    int
-	GetAllCalls int
-	CloseCalls  int
+	InsertCalls      int
+	InsertBatchCalls int
+	GetCalls         int
+	GetAllCalls      int
+	GetByTrackCalls  int
+	FindCalls        int
+	SearchCalls      int
+	GetCustomerCalls int
+	RelayCalls       int
+	CleanupCalls     int
+	CloseCalls       int
 
-	LastInsert model.Order
-	LastGetID  string
+	LastInsert      model.Order
+	LastInsertBatch []model.Order
+	LastGetID       string
+	LastGetTrack    string
+	LastFindFilter  OrderFilter
+	LastSearch      string
+	LastCustomerID  string
 }
 
 func (m *MockDB) InsertOrder(order model.Order) error {
@@ -34,6 +56,18 @@ func (m *MockDB) InsertOrder(order model.Order) error {
 	return nil
 }
 
+func (m *MockDB) InsertOrders(orders []model.Order) (int, error) {
+	m.mu.Lock()
+	m.InsertBatchCalls++
+	m.LastInsertBatch = orders
+	m.mu.Unlock()
+
+	if m.InsertOrdersFunc != nil {
+		return m.InsertOrdersFunc(orders)
+	}
+	return len(orders), nil
+}
+
 func (m *MockDB) GetOrder(id string) (model.Order, error) {
 	m.mu.Lock()
 	m.GetCalls++
@@ -57,6 +91,83 @@ func (m *MockDB) GetAllOrders() ([]model.Order, error) {
 	return nil, nil
 }
 
+func (m *MockDB) GetOrderByTrack(track string) (model.Order, error) {
+	m.mu.Lock()
+	m.GetByTrackCalls++
+	m.LastGetTrack = track
+	m.mu.Unlock()
+
+	if m.GetByTrackFunc != nil {
+		return m.GetByTrackFunc(track)
+	}
+	return model.Order{}, nil
+}
+
+func (m *MockDB) FindOrders(f OrderFilter) ([]model.Order, error) {
+	m.mu.Lock()
+	m.FindCalls++
+	m.LastFindFilter = f
+	m.mu.Unlock()
+
+	if m.FindOrdersFunc != nil {
+		return m.FindOrdersFunc(f)
+	}
+	return nil, nil
+}
+
+func (m *MockDB) SearchOrders(query string, limit, offset int) ([]SearchResult, int, error) {
+	m.mu.Lock()
+	m.SearchCalls++
+	m.LastSearch = query
+	m.mu.Unlock()
+
+	if m.SearchFunc != nil {
+		return m.SearchFunc(query, limit, offset)
+	}
+	return nil, 0, nil
+}
+
+func (m *MockDB) GetCustomerOrders(customerID string, limit, offset int) (CustomerOrders, error) {
+	m.mu.Lock()
+	m.GetCustomerCalls++
+	m.LastCustomerID = customerID
+	m.mu.Unlock()
+
+	if m.GetCustomerFunc != nil {
+		return m.GetCustomerFunc(customerID, limit, offset)
+	}
+	return CustomerOrders{}, nil
+}
+
+func (m *MockDB) StreamOrders(ctx context.Context, f ExportFilter, fn func(model.Order) error) error {
+	if m.StreamOrdersFunc != nil {
+		return m.StreamOrdersFunc(f, fn)
+	}
+	return nil
+}
+
+func (m *MockDB) RelayOutbox(ctx context.Context, limit int, publish func([]OutboxEvent) error) (int, error) {
+	m.mu.Lock()
+	m.RelayCalls++
+	m.mu.Unlock()
+
+	if m.RelayOutboxFunc != nil {
+		return m.RelayOutboxFunc(limit, publish)
+	}
+	return 0, nil
+}
+
+func (m *MockDB) CleanupOutbox(ctx context.Context, sentBefore time.Time) (int64, error) {
+	m.mu.Lock()
+	m.CleanupCalls++
+	m.mu.Unlock()
+
+	if m.CleanupFunc != nil {
+		return m.CleanupFunc(sentBefore)
+	}
+	return 0, nil
+}
+
 func (m *MockDB) Close() {
 	m.mu.Lock()
 	m.CloseCalls++
@@ -0,0 +1,290 @@
+package database
+
+import (
+	"awesomeProject3/project/keyring"
+	"awesomeProject3/project/model"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// orderColumns is the column list every order read selects; scanOrder
+// expects them in this order.
+const orderColumns = "data, created_at, key_id, dek, secret, source_topic"
+
+// insertOrderSQL stores an order and, only if it wasn't there yet, its
+// OrderStored outbox event. Rows affected counts the inserted orders.
+const insertOrderSQL = `WITH inserted AS (
+	INSERT INTO orders
+	(order_uid, data, key_id, dek, secret, email_bidx, phone_bidx, transaction_bidx, source_topic, address_search)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, array_to_tsvector($10::text[])) ON CONFLICT DO NOTHING
+	RETURNING order_uid
+)
+INSERT INTO outbox (aggregate_id, event_type, payload)
+SELECT order_uid, $11::text, $12::jsonb FROM inserted`
+
+// Blind index names, also used as the hash domain of each field.
+const (
+	bidxEmail       = "email"
+	bidxPhone       = "phone"
+	bidxTransaction = "transaction"
+	bidxAddress     = "address"
+)
+
+// orderSecrets are the fields stored encrypted when a keyring is configured.
+// They are blanked in the data column.
+type orderSecrets struct {
+	Name        string `json:"name"`
+	Phone       string `json:"phone"`
+	Zip         string `json:"zip"`
+	Address     string `json:"address"`
+	Email       string `json:"email"`
+	Transaction string `json:"transaction"`
+}
+
+func extractSecrets(o *model.Order) orderSecrets {
+	s := orderSecrets{
+		Name:        o.Delivery.Name,
+		Phone:       o.Delivery.Phone,
+		Zip:         o.Delivery.Zip,
+		Address:     o.Delivery.Address,
+		Email:       o.Delivery.Email,
+		Transaction: o.Payment.Transaction,
+	}
+	o.Delivery.Name, o.Delivery.Phone, o.Delivery.Zip = "", "", ""
+	o.Delivery.Address, o.Delivery.Email, o.Payment.Transaction = "", "", ""
+	return s
+}
+
+func (s orderSecrets) apply(o *model.Order) {
+	o.Delivery.Name = s.Name
+	o.Delivery.Phone = s.Phone
+	o.Delivery.Zip = s.Zip
+	o.Delivery.Address = s.Address
+	o.Delivery.Email = s.Email
+	o.Payment.Transaction = s.Transaction
+}
+
+type orderRow struct {
+	data     []byte
+	keyID    *string
+	dek      []byte
+	secret   []byte
+	emailIdx *string
+	phoneIdx *string
+	txIdx    *string
+	topic    *string
+	// addressIdx feeds the search trigger for encrypted rows, whose data
+	// column has the address blanked: one blind index per address word.
+	addressIdx []string
+}
+
+func (r orderRow) args(orderUID string) []any {
+	return []any{orderUID, r.data, r.keyID, r.dek, r.secret, r.emailIdx, r.phoneIdx, r.txIdx, r.topic, r.addressIdx}
+}
+
+// encodeRow serializes o, sealing its secrets when a keyring is configured.
+func (db *Database) encodeRow(o model.Order) (orderRow, error) {
+	var topic *string
+	if o.SourceTopic != "" {
+		topic = &o.SourceTopic
+	}
+	if db.Keyring == nil {
+		data, err := encodeOrder(o)
+		return orderRow{data: data, topic: topic}, err
+	}
+
+	secrets := extractSecrets(&o)
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return orderRow{}, err
+	}
+	sealed, err := db.Keyring.Seal(plain, []byte(o.OrderUID))
+	if err != nil {
+		return orderRow{}, err
+	}
+	data, err := encodeOrder(o)
+	if err != nil {
+		return orderRow{}, err
+	}
+
+	return orderRow{
+		data:     data,
+		keyID:    &sealed.KeyID,
+		dek:      sealed.DEK,
+		secret:   sealed.Ciphertext,
+		emailIdx: db.blindIndex(bidxEmail, secrets.Email),
+		phoneIdx: db.blindIndex(bidxPhone, secrets.Phone),
+		txIdx:    db.blindIndex(bidxTransaction, secrets.Transaction),
+		topic:    topic,
+		// Never nil, so that rotation can tell these rows are indexed.
+		addressIdx: blindIndexes(db.Keyring, bidxAddress, searchWords(secrets.Address)),
+	}, nil
+}
+
+func blindIndexes(k *keyring.Keyring, field string, words []string) []string {
+	idx := make([]string, len(words))
+	for i, w := range words {
+		idx[i] = k.BlindIndex(field, w)
+	}
+	return idx
+}
+
+// searchWords returns the distinct words of s, split the way the 'simple'
+// text search configuration splits plain words.
+func searchWords(s string) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, w := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !seen[w] {
+			seen[w] = true
+			words = append(words, w)
+		}
+	}
+	return words
+}
+
+// searchQueryArgs returns the words of query and their address blind
+// indexes for orders_search_query, which lets each word also match an
+// encrypted address. Both are empty without a keyring.
+func (db *Database) searchQueryArgs(query string) ([]string, []string) {
+	if db.Keyring == nil {
+		return []string{}, []string{}
+	}
+	words := searchWords(query)
+	return words, blindIndexes(db.Keyring, bidxAddress, words)
+}
+
+func (db *Database) blindIndex(field, value string) *string {
+	if value == "" {
+		return nil
+	}
+	idx := db.Keyring.BlindIndex(field, value)
+	return &idx
+}
+
+// scanOrder reads orderColumns followed by extra destinations and opens the
+// sealed secrets. Rows written without a keyring have no key_id and are
+// returned as stored.
+func (db *Database) scanOrder(row pgx.Row, extra ...any) (model.Order, error) {
+	var (
+		data      []byte
+		createdAt *time.Time
+		keyID     *string
+		dek       []byte
+		secret    []byte
+		topic     *string
+	)
+	dest := append([]any{&data, &createdAt, &keyID, &dek, &secret, &topic}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return model.Order{}, err
+	}
+
+	o, err := decodeOrderRow(data, createdAt)
+	if err == nil && topic != nil {
+		o.SourceTopic = *topic
+	}
+	if err != nil || keyID == nil {
+		return o, err
+	}
+	if db.Keyring == nil {
+		return model.Order{}, fmt.Errorf("order %s is encrypted but no keyring is configured", o.OrderUID)
+	}
+
+	plain, err := db.Keyring.Open(keyring.Sealed{KeyID: *keyID, DEK: dek, Ciphertext: secret}, []byte(o.OrderUID))
+	if err != nil {
+		return model.Order{}, fmt.Errorf("decrypt order %s: %w", o.OrderUID, err)
+	}
+	var secrets orderSecrets
+	if err := json.Unmarshal(plain, &secrets); err != nil {
+		return model.Order{}, err
+	}
+	secrets.apply(&o)
+	return o, nil
+}
+
+// RotateKeys re-encrypts, in batches, every row that is not sealed with the
+// active key, including rows stored before encryption was enabled, and fills
+// address_search for rows encrypted before it existed. It returns the number
+// of rows rewritten.
+func (db *Database) RotateKeys(ctx context.Context, batchSize int) (int, error) {
+	if db.Keyring == nil {
+		return 0, fmt.Errorf("no keyring configured")
+	}
+
+	active := db.Keyring.ActiveKeyID()
+	rotated := 0
+	last := ""
+	for {
+		n, next, err := db.rotateBatch(ctx, active, last, batchSize)
+		rotated += n
+		if err != nil || next == "" {
+			return rotated, err
+		}
+		last = next
+	}
+}
+
+// rotateBatch rewrites up to batchSize rows after order_uid last. It returns
+// the last order_uid it looked at, or "" when there are no more rows.
+func (db *Database) rotateBatch(ctx context.Context, active, last string, batchSize int) (int, string, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		"SELECT "+orderColumns+", order_uid FROM orders"+
+			" WHERE order_uid > $1 AND (key_id IS DISTINCT FROM $2 OR address_search IS NULL)"+
+			" ORDER BY order_uid LIMIT $3 FOR UPDATE",
+		last, active, batchSize,
+	)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var orders []model.Order
+	var uids []string
+	for rows.Next() {
+		var uid string
+		o, err := db.scanOrder(rows, &uid)
+		if err != nil {
+			rows.Close()
+			return 0, "", err
+		}
+		orders = append(orders, o)
+		uids = append(uids, uid)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, "", err
+	}
+	if len(orders) == 0 {
+		return 0, "", nil
+	}
+
+	batch := &pgx.Batch{}
+	for i, o := range orders {
+		r, err := db.encodeRow(o)
+		if err != nil {
+			return 0, "", err
+		}
+		batch.Queue(`UPDATE orders SET data = $2, key_id = $3, dek = $4, secret = $5,
+			email_bidx = $6, phone_bidx = $7, transaction_bidx = $8, source_topic = $9,
+			address_search = array_to_tsvector($10::text[]) WHERE order_uid = $1`,
+			r.args(uids[i])...)
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return 0, "", err
+	}
+
+	return len(orders), uids[len(uids)-1], tx.Commit(ctx)
+}
@@ -0,0 +1,24 @@
+package database
+
+import (
+	"errors"
+	"net"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/puddle/v2"
+)
+
+// ErrExists is returned by InsertOrder when an order with the same order_uid
+// is already stored. The stored order is left unchanged.
+var ErrExists = errors.New("order already exists")
+
+// IsUnavailable reports whether err means the database could not be reached,
+// as opposed to a failed query.
+func IsUnavailable(err error) bool {
+	var connErr *pgconn.ConnectError
+	var netErr *net.OpError
+	return errors.As(err, &connErr) ||
+		errors.As(err, &netErr) ||
+		errors.Is(err, puddle.ErrClosedPool) ||
+		pgconn.SafeToRetry(err)
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"awesomeProject3/project/model"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// OutboxEvent is a row of the outbox table, written in the same transaction
+// as the order it describes.
+type OutboxEvent struct {
+	ID          int64
+	AggregateID string
+	Type        string
+	Payload     []byte
+	CreatedAt   time.Time
+}
+
+func orderStoredPayload(o model.Order) ([]byte, error) {
+	return json.Marshal(model.OrderStored{
+		OrderUID:    o.OrderUID,
+		TrackNumber: o.TrackNumber,
+		CustomerID:  o.CustomerID,
+		SourceTopic: o.SourceTopic,
+		StoredAt:    time.Now().UTC(),
+	})
+}
+
+// insertArgs are the parameters of insertOrderSQL.
+func (db *Database) insertArgs(o model.Order) ([]any, error) {
+	row, err := db.encodeRow(o)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := orderStoredPayload(o)
+	if err != nil {
+		return nil, err
+	}
+	return append(row.args(o.OrderUID), model.EventOrderStored, payload), nil
+}
+
+// RelayOutbox locks up to limit unsent events, hands them to publish and
+// marks them sent once publish succeeds. Locked rows are skipped, so several
+// relays can run at once. If publish fails nothing is marked and the events
+// are offered again on the next call.
+func (db *Database) RelayOutbox(ctx context.Context, limit int, publish func([]OutboxEvent) error) (int, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT id, aggregate_id, event_type, payload, created_at
+		FROM outbox
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED`,
+		limit,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		events []OutboxEvent
+		ids    []int64
+	)
+	for rows.Next() {
+		var e OutboxEvent
+		if err := rows.Scan(&e.ID, &e.AggregateID, &e.Type, &e.Payload, &e.CreatedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		events = append(events, e)
+		ids = append(ids, e.ID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := publish(events); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, "UPDATE outbox SET sent_at = now() WHERE id = ANY($1)", ids); err != nil {
+		return 0, err
+	}
+	return len(events), tx.Commit(ctx)
+}
+
+// CleanupOutbox deletes events sent before the given time.
+func (db *Database) CleanupOutbox(ctx context.Context, sentBefore time.Time) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, "DELETE FROM outbox WHERE sent_at < $1", sentBefore)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"awesomeProject3/project/auth"
+	"awesomeProject3/project/model"
+	"errors"
+	"net/http"
+)
+
+// authorize requires scope for h. It is a no-op when authentication is
+// disabled. An IP that keeps failing authentication gets 429 before its
+// credentials are checked, so guessing stops even for a correct guess.
+func (s *Server) authorize(scope string, h http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Auth == nil {
+			h(w, r)
+			return
+		}
+
+		failures := s.authFailureLimiter()
+		ip := "ip:" + s.clientIP(r)
+		if failures != nil {
+			if res := failures.Peek(ip); !res.Allowed {
+				s.tooManyRequests(w, r, res)
+				return
+			}
+		}
+
+		p, err := s.Auth.Authenticate(r)
+		if err != nil {
+			if failures != nil {
+				failures.Allow(ip)
+			}
+			detail := "Authentication required"
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				detail = "Invalid credentials"
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="orders"`)
+			s.problem(w, r, http.StatusUnauthorized, detail)
+			return
+		}
+		if !p.Has(scope) {
+			s.problem(w, r, http.StatusForbidden, "Missing scope "+scope)
+			return
+		}
+
+		h(w, r.WithContext(auth.NewContext(r.Context(), p)))
+	})
+}
+
+// restrictedTo returns the customer the caller is limited to, or "" when
+// the caller may see every order.
+func restrictedTo(r *http.Request) string {
+	p, _ := auth.FromContext(r.Context())
+	return p.CustomerID
+}
+
+// redacts reports whether personal data must be masked for the caller.
+// Unauthenticated requests follow MaskPII.
+func (s *Server) redacts(r *http.Request) bool {
+	if p, ok := auth.FromContext(r.Context()); ok {
+		return !p.Has(auth.ScopePII)
+	}
+	return s.MaskPII
+}
+
+func canSee(r *http.Request, o model.Order) bool {
+	p, _ := auth.FromContext(r.Context())
+	return p.CanSee(o)
+}
+
+func visibleOrders(r *http.Request, orders []model.Order) []model.Order {
+	if restrictedTo(r) == "" {
+		return orders
+	}
+	out := orders[:0:0]
+	for _, o := range orders {
+		if canSee(r, o) {
+			out = append(out, o)
+		}
+	}
+	return out
+}
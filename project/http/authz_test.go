@@ -0,0 +1,95 @@
+package http
+
+import (
+	"awesomeProject3/project/auth"
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func newAuthServer(db *database.MockDB) *Server {
+	s := NewServer(db, &cache.MockCache{})
+	s.Auth = auth.New(auth.Config{APIKeys: map[string]auth.Principal{
+		"reader":   {Subject: "reader", Scopes: []string{auth.ScopeRead}},
+		"customer": {Subject: "c-1", Scopes: []string{auth.ScopeRead}, CustomerID: "c-1"},
+	}})
+	return s
+}
+
+func authGet(s *Server, h http.HandlerFunc, scope, key string, vars map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req = mux.SetURLVars(req, vars)
+	if key != "" {
+		req.Header.Set(auth.APIKeyHeader, key)
+	}
+	rr := httptest.NewRecorder()
+	s.authorize(scope, h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestAuthorize_Statuses(t *testing.T) {
+	db := &database.MockDB{GetOrderFunc: func(string) (model.Order, error) { return validOrder("o-1"), nil }}
+	s := newAuthServer(db)
+	vars := map[string]string{"order_uid": "o-1"}
+
+	if rr := authGet(s, s.GetOrderByPath, auth.ScopeRead, "", vars); rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") == "" {
+		t.Fatalf("expected 401 with challenge, got %d", rr.Code)
+	}
+	if rr := authGet(s, s.GetOrderByPath, auth.ScopeRead, "wrong", vars); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for unknown key, got %d", rr.Code)
+	}
+	if rr := authGet(s, s.CreateOrders, auth.ScopeWrite, "reader", nil); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without write scope, got %d", rr.Code)
+	}
+	if rr := authGet(s, s.GetOrderByPath, auth.ScopeRead, "reader", vars); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for reader, got %d", rr.Code)
+	}
+}
+
+func TestAuthorize_CustomerRestriction(t *testing.T) {
+	db := &database.MockDB{GetOrderFunc: func(id string) (model.Order, error) {
+		o := validOrder(id)
+		o.CustomerID = "c-2"
+		return o, nil
+	}}
+	s := newAuthServer(db)
+
+	if rr := authGet(s, s.GetOrderByPath, auth.ScopeRead, "customer", map[string]string{"order_uid": "o-2"}); rr.Code != http.StatusNotFound {
+		t.Fatalf("expected other customer's order to be hidden, got %d", rr.Code)
+	}
+	if rr := authGet(s, s.GetCustomerOrders, auth.ScopeRead, "customer", map[string]string{"customer_id": "c-2"}); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for other customer's history, got %d", rr.Code)
+	}
+	if rr := authGet(s, s.GetCustomerOrders, auth.ScopeRead, "customer", map[string]string{"customer_id": "c-1"}); rr.Code != http.StatusOK {
+		t.Fatalf("expected own history to be readable, got %d", rr.Code)
+	}
+}
+
+func TestRespond_MasksPIIWithoutScope(t *testing.T) {
+	db := &database.MockDB{GetOrderFunc: func(id string) (model.Order, error) { return validOrder(id), nil }}
+	s := NewServer(db, &cache.MockCache{})
+	s.Auth = auth.New(auth.Config{APIKeys: map[string]auth.Principal{
+		"reader":  {Scopes: []string{auth.ScopeRead}},
+		"support": {Scopes: []string{auth.ScopeRead, auth.ScopePII}},
+	}})
+	vars := map[string]string{"order_uid": "pii-1"}
+
+	masked := authGet(s, s.GetOrderByPath, auth.ScopeRead, "reader", vars)
+	raw := authGet(s, s.GetOrderByPath, auth.ScopeRead, "support", vars)
+
+	if strings.Contains(masked.Body.String(), "john@example.com") || !strings.Contains(masked.Body.String(), "j***@example.com") {
+		t.Fatalf("expected masked email, got %s", masked.Body.String())
+	}
+	if !strings.Contains(raw.Body.String(), "john@example.com") {
+		t.Fatalf("expected raw email with pii scope, got %s", raw.Body.String())
+	}
+	if masked.Header().Get("ETag") == raw.Header().Get("ETag") {
+		t.Fatalf("expected masked and raw representations to have different etags")
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	cases := map[string]string{
+		"":                       "",
+		"identity":               "",
+		"gzip":                   "gzip",
+		"gzip, deflate, br":      "br",
+		"br;q=0.5, gzip":         "gzip",
+		"br;q=0, gzip;q=0.1":     "gzip",
+		"GZIP;q=0.8, *;q=0.1":    "gzip",
+		"br;q=abc, gzip;q=0.001": "gzip",
+	}
+	for header, want := range cases {
+		if got := negotiateEncoding(header); got != want {
+			t.Fatalf("expected %q for %q, got %q", want, header, got)
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	body := strings.Repeat(`{"order_uid":"o-1"}`, 100)
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", mediaJSON)
+		w.Header().Set("ETag", `"abc"`)
+		w.Write([]byte(body))
+	}
+
+	rr := serveMiddleware(s, handler, http.Header{"Accept-Encoding": {"gzip"}})
+	if rr.Header().Get("Content-Encoding") != "gzip" || rr.Header().Get("ETag") != `W/"abc"` {
+		t.Fatalf("expected gzip with weak etag, got %v", rr.Header())
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, _ := io.ReadAll(zr); string(got) != body {
+		t.Fatalf("expected body to round-trip, got %q", got)
+	}
+
+	rr = serveMiddleware(s, handler, http.Header{"Accept-Encoding": {"gzip, br"}})
+	if rr.Header().Get("Content-Encoding") != "br" {
+		t.Fatalf("expected br, got %v", rr.Header())
+	}
+	if got, _ := io.ReadAll(brotli.NewReader(rr.Body)); string(got) != body {
+		t.Fatalf("expected body to round-trip, got %q", got)
+	}
+
+	rr = serveMiddleware(s, func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("small")) },
+		http.Header{"Accept-Encoding": {"gzip"}})
+	if rr.Header().Get("Content-Encoding") != "" || rr.Body.String() != "small" {
+		t.Fatalf("expected small body uncompressed, got %v %q", rr.Header(), rr.Body.String())
+	}
+	if rr.Header().Get("Vary") != "Accept-Encoding" {
+		t.Fatalf("expected Vary: Accept-Encoding, got %q", rr.Header().Get("Vary"))
+	}
+}
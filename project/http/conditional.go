@@ -0,0 +1,94 @@
+package http
+
+import (
+	"awesomeProject3/project/cache"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Orders never change once stored, but clients must revalidate so a
+// re-imported order is picked up.
+const orderCacheControl = "private, no-cache"
+
+// orderEntry returns the cached order with its validators, falling back to
+// the database.
+func (s *Server) orderEntry(orderID string) (cache.Entry, error) {
+	if ec, ok := s.Cache.(cache.EntryCache); ok {
+		if e, ok := ec.GetEntry(orderID); ok {
+			return e, nil
+		}
+	} else if order, ok := s.Cache.Get(orderID); ok {
+		return cache.Entry{Order: order, ETag: cache.ETag(order), Modified: order.CreatedAt}, nil
+	}
+
+	order, err := s.DB.GetOrder(orderID)
+	if err != nil {
+		return cache.Entry{}, err
+	}
+	s.Cache.Set(orderID, order)
+	return cache.Entry{Order: order, ETag: cache.ETag(order), Modified: order.CreatedAt}, nil
+}
+
+// respondEntry writes the order, or 304 when the client's copy is current.
+// Each representation gets its own strong ETag.
+func (s *Server) respondEntry(w http.ResponseWriter, r *http.Request, e cache.Entry) {
+	mediaType, _, ok := s.Encoders.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		s.respond(w, r, http.StatusOK, e.Order)
+		return
+	}
+
+	etag := entityTag(e.ETag, mediaType, isPretty(r), s.redacts(r))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", orderCacheControl)
+	if !e.Modified.IsZero() {
+		w.Header().Set("Last-Modified", e.Modified.UTC().Format(http.TimeFormat))
+	}
+
+	if notModified(r, etag, e.Modified) {
+		w.Header().Add("Vary", "Accept")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	s.respond(w, r, http.StatusOK, e.Order)
+}
+
+func entityTag(hash, mediaType string, pretty, redacted bool) string {
+	tag := hash
+	if mediaType != mediaJSON {
+		_, sub, _ := strings.Cut(mediaType, "/")
+		tag += "-" + strings.TrimPrefix(sub, "x-")
+	} else if pretty {
+		tag += "-pretty"
+	}
+	if redacted {
+		tag += "-redacted"
+	}
+	return `"` + tag + `"`
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when the former
+// is absent (RFC 9110, section 13.2.2).
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+			if candidate == "*" || candidate == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	ims := r.Header.Get("If-Modified-Since")
+	if ims == "" || modified.IsZero() {
+		return false
+	}
+	t, err := http.ParseTime(ims)
+	if err != nil {
+		return false
+	}
+	return !modified.Truncate(time.Second).After(t)
+}
@@ -0,0 +1,85 @@
+package http
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func conditionalGet(t *testing.T, s *Server, uid string, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/order/"+uid, nil)
+	req = mux.SetURLVars(req, map[string]string{"order_uid": uid})
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rr := httptest.NewRecorder()
+	s.GetOrderByPath(rr, req)
+	return rr
+}
+
+func TestGetOrderByPath_ETagAndNotModified(t *testing.T) {
+	order := validOrder("etag-1")
+	order.CreatedAt = time.Date(2021, 11, 26, 6, 22, 19, 0, time.UTC)
+	c := cache.New(time.Minute)
+	c.Set(order.OrderUID, order)
+	s := NewServer(&database.MockDB{}, c)
+
+	rr := conditionalGet(t, s, "etag-1", nil)
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || etag == "" {
+		t.Fatalf("expected 200 with etag, got %d %q", rr.Code, etag)
+	}
+	if got := rr.Header().Get("Last-Modified"); got != "Fri, 26 Nov 2021 06:22:19 GMT" {
+		t.Fatalf("expected last-modified from created_at, got %q", got)
+	}
+	if rr.Header().Get("Cache-Control") == "" {
+		t.Fatalf("expected cache-control header")
+	}
+
+	rr = conditionalGet(t, s, "etag-1", map[string]string{"If-None-Match": `"other", ` + etag})
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Fatalf("expected empty 304, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	rr = conditionalGet(t, s, "etag-1", map[string]string{"If-None-Match": `"other"`})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for stale etag, got %d", rr.Code)
+	}
+}
+
+func TestGetOrderByPath_IfModifiedSince(t *testing.T) {
+	order := validOrder("ims-1")
+	order.CreatedAt = time.Date(2021, 11, 26, 6, 22, 19, 500, time.UTC)
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	s.DB.(*database.MockDB).GetOrderFunc = func(string) (model.Order, error) { return order, nil }
+
+	rr := conditionalGet(t, s, "ims-1", map[string]string{"If-Modified-Since": "Fri, 26 Nov 2021 06:22:19 GMT"})
+	if rr.Code != http.StatusNotModified {
+		t.Fatalf("expected 304, got %d", rr.Code)
+	}
+
+	rr = conditionalGet(t, s, "ims-1", map[string]string{"If-Modified-Since": "Thu, 25 Nov 2021 00:00:00 GMT"})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 for older date, got %d", rr.Code)
+	}
+}
+
+func TestGetOrderByPath_ETagPerRepresentation(t *testing.T) {
+	order := validOrder("etag-2")
+
+	jsonTag := getOrder(t, order, "/order/etag-2", "").Header().Get("ETag")
+	csvTag := getOrder(t, order, "/order/etag-2", "text/csv").Header().Get("ETag")
+	prettyTag := getOrder(t, order, "/order/etag-2?pretty=1", "").Header().Get("ETag")
+
+	if jsonTag == csvTag || jsonTag == prettyTag {
+		t.Fatalf("expected distinct etags, got %s %s %s", jsonTag, csvTag, prettyTag)
+	}
+}
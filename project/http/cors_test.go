@@ -0,0 +1,93 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func corsRequest(t *testing.T, cfg CORSConfig, method, origin string, headers map[string]string) (*httptest.ResponseRecorder, bool) {
+	t.Helper()
+
+	called := false
+	h := cfg.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	req := httptest.NewRequest(method, "/order/x", nil)
+	if origin != "" {
+		req.Header.Set("Origin", origin)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr, called
+}
+
+func TestCORS_DefaultWildcardOnErrors(t *testing.T) {
+	rr, _ := corsRequest(t, DefaultCORSConfig(), http.MethodGet, "https://shop.example", nil)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected handler status, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Fatalf("expected wildcard origin on error response, got %q", got)
+	}
+	if rr.Header().Get("Access-Control-Expose-Headers") == "" {
+		t.Fatalf("expected exposed headers")
+	}
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	cfg := CORSConfig{
+		AllowedOrigins:   []string{"https://shop.example"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	}
+
+	rr, called := corsRequest(t, cfg, http.MethodOptions, "https://shop.example", map[string]string{
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "content-type",
+	})
+
+	if called || rr.Code != http.StatusNoContent {
+		t.Fatalf("expected preflight to be answered by middleware, got %d called=%v", rr.Code, called)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://shop.example" {
+		t.Fatalf("expected origin to be echoed, got %q", got)
+	}
+	if rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+		t.Fatalf("expected credentials to be allowed")
+	}
+	if rr.Header().Get("Access-Control-Allow-Methods") != "GET, POST" || rr.Header().Get("Access-Control-Max-Age") != "3600" {
+		t.Fatalf("unexpected preflight headers: %v", rr.Header())
+	}
+
+	rr, _ = corsRequest(t, cfg, http.MethodOptions, "https://shop.example", map[string]string{
+		"Access-Control-Request-Method":  "POST",
+		"Access-Control-Request-Headers": "X-Secret",
+	})
+	if rr.Header().Get("Access-Control-Allow-Methods") != "" {
+		t.Fatalf("expected preflight with disallowed header to be refused")
+	}
+}
+
+func TestCORS_UnknownOrigin(t *testing.T) {
+	cfg := DefaultCORSConfig()
+	cfg.AllowedOrigins = []string{"https://shop.example"}
+
+	rr, called := corsRequest(t, cfg, http.MethodGet, "https://evil.example", nil)
+
+	if !called {
+		t.Fatalf("expected request to reach handler")
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no CORS headers for unknown origin, got %q", got)
+	}
+}
@@ -0,0 +1,227 @@
+package http
+
+import (
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/ordercsv"
+	"awesomeProject3/project/orderpb"
+	"awesomeProject3/project/redact"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	mediaJSON     = "application/json"
+	mediaCSV      = "text/csv"
+	mediaMsgpack  = "application/msgpack"
+	mediaProtobuf = "application/x-protobuf"
+)
+
+var errUnsupportedValue = errors.New("value can't be represented in this format")
+
+type Encoder interface {
+	Encode(w io.Writer, v any) error
+}
+
+type EncoderFunc func(w io.Writer, v any) error
+
+func (f EncoderFunc) Encode(w io.Writer, v any) error { return f(w, v) }
+
+// orderLister is implemented by responses that wrap a list of orders, so
+// order-only formats such as CSV and protobuf can render them.
+type orderLister interface {
+	listOrders() []model.Order
+}
+
+// Encoders maps media types to encoders. The first registered type is used
+// when the client does not express a preference.
+type Encoders struct {
+	types    []string
+	encoders map[string]Encoder
+	aliases  map[string]string
+}
+
+func NewEncoders() *Encoders {
+	e := &Encoders{
+		encoders: make(map[string]Encoder),
+		aliases:  make(map[string]string),
+	}
+	e.Register(mediaJSON, EncoderFunc(encodeJSON))
+	e.Register(mediaCSV, EncoderFunc(encodeCSV))
+	e.Register(mediaMsgpack, EncoderFunc(encodeMsgpack), "application/x-msgpack", "application/vnd.msgpack")
+	e.Register(mediaProtobuf, EncoderFunc(encodeProtobuf), "application/protobuf", "application/vnd.google.protobuf")
+	return e
+}
+
+func (e *Encoders) Register(mediaType string, enc Encoder, aliases ...string) {
+	if _, ok := e.encoders[mediaType]; !ok {
+		e.types = append(e.types, mediaType)
+	}
+	e.encoders[mediaType] = enc
+	for _, a := range aliases {
+		e.aliases[a] = mediaType
+	}
+}
+
+// Negotiate picks the media type for the request's Accept header. It returns
+// false when none of the acceptable types is registered.
+func (e *Encoders) Negotiate(accept string) (string, Encoder, bool) {
+	if strings.TrimSpace(accept) == "" {
+		return e.types[0], e.encoders[e.types[0]], true
+	}
+
+	for _, rng := range parseAccept(accept) {
+		if rng.q <= 0 {
+			continue
+		}
+		if mt, ok := e.aliases[rng.media]; ok {
+			return mt, e.encoders[mt], true
+		}
+		if enc, ok := e.encoders[rng.media]; ok {
+			return rng.media, enc, true
+		}
+		if rng.media == "*/*" {
+			return e.types[0], e.encoders[e.types[0]], true
+		}
+		if prefix, ok := strings.CutSuffix(rng.media, "/*"); ok {
+			for _, mt := range e.types {
+				if strings.HasPrefix(mt, prefix+"/") {
+					return mt, e.encoders[mt], true
+				}
+			}
+		}
+	}
+	return "", nil, false
+}
+
+type acceptRange struct {
+	media string
+	q     float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var out []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		rng := acceptRange{media: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		if rng.media == "" {
+			continue
+		}
+		for _, p := range params[1:] {
+			k, v, _ := strings.Cut(strings.TrimSpace(p), "=")
+			if k == "q" {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					rng.q = q
+				}
+			}
+		}
+		out = append(out, rng)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].q > out[j].q })
+	return out
+}
+
+// respond encodes v in the format negotiated from the Accept header. JSON is
+// indented when the request has ?pretty=1.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, status int, v any) {
+	mediaType, enc, ok := s.Encoders.Negotiate(r.Header.Get("Accept"))
+	if !ok {
+		s.problem(w, r, http.StatusNotAcceptable, "Not acceptable")
+		return
+	}
+
+	if s.redacts(r) {
+		v = redact.Value(v)
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if mediaType == mediaJSON && isPretty(r) {
+		e := json.NewEncoder(&buf)
+		e.SetIndent("", "  ")
+		err = e.Encode(v)
+	} else {
+		err = enc.Encode(&buf, v)
+	}
+	if errors.Is(err, errUnsupportedValue) {
+		s.problem(w, r, http.StatusNotAcceptable, "Not acceptable")
+		return
+	}
+	if err != nil {
+		s.problem(w, r, http.StatusInternalServerError, "Can't encode response")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType(mediaType))
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func isPretty(r *http.Request) bool {
+	v := r.URL.Query().Get("pretty")
+	return v == "1" || v == "true"
+}
+
+func contentType(mediaType string) string {
+	if strings.HasPrefix(mediaType, "text/") {
+		return mediaType + "; charset=utf-8"
+	}
+	return mediaType
+}
+
+func encodeJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeMsgpack(w io.Writer, v any) error {
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	return enc.Encode(v)
+}
+
+func ordersOf(v any) ([]model.Order, bool, bool) {
+	switch t := v.(type) {
+	case model.Order:
+		return []model.Order{t}, true, true
+	case []model.Order:
+		return t, false, true
+	case orderLister:
+		return t.listOrders(), false, true
+	}
+	return nil, false, false
+}
+
+func encodeCSV(w io.Writer, v any) error {
+	orders, _, ok := ordersOf(v)
+	if !ok {
+		return errUnsupportedValue
+	}
+	cw := ordercsv.NewWriter(w)
+	for _, o := range orders {
+		if err := cw.Write(o); err != nil {
+			return err
+		}
+	}
+	return cw.Flush()
+}
+
+func encodeProtobuf(w io.Writer, v any) error {
+	orders, single, ok := ordersOf(v)
+	if !ok {
+		return errUnsupportedValue
+	}
+	if single {
+		_, err := w.Write(orderpb.Marshal(orders[0]))
+		return err
+	}
+	_, err := w.Write(orderpb.MarshalList(orders))
+	return err
+}
@@ -0,0 +1,144 @@
+package http
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/orderpb"
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestEncoders_Negotiate(t *testing.T) {
+	e := NewEncoders()
+
+	cases := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", mediaJSON, true},
+		{"*/*", mediaJSON, true},
+		{"text/csv", mediaCSV, true},
+		{"text/*", mediaCSV, true},
+		{"application/x-msgpack", mediaMsgpack, true},
+		{"application/protobuf", mediaProtobuf, true},
+		{"text/html, application/x-protobuf;q=0.5, text/csv;q=0.9", mediaCSV, true},
+		{"application/json;q=0, text/csv;q=0.1", mediaCSV, true},
+		{"image/png", "", false},
+	}
+
+	for _, tc := range cases {
+		got, _, ok := e.Negotiate(tc.accept)
+		if ok != tc.ok || got != tc.want {
+			t.Fatalf("Negotiate(%q) = %q, %v; want %q, %v", tc.accept, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func getOrder(t *testing.T, order model.Order, target, accept string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	c := &cache.MockCache{GetFunc: func(string) (model.Order, bool) { return order, true }}
+	s := NewServer(&database.MockDB{}, c)
+
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req = mux.SetURLVars(req, map[string]string{"order_uid": order.OrderUID})
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rr := httptest.NewRecorder()
+	s.GetOrderByPath(rr, req)
+	return rr
+}
+
+func TestGetOrderByPath_PrettyJSON(t *testing.T) {
+	rr := getOrder(t, validOrder("fmt-1"), "/order/fmt-1?pretty=1", "")
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+	if !strings.Contains(rr.Body.String(), "\n  \"order_uid\": \"fmt-1\"") {
+		t.Fatalf("expected indented json, got %s", rr.Body.String())
+	}
+}
+
+func TestGetOrderByPath_CSV_RowPerItem(t *testing.T) {
+	order := validOrder("fmt-2")
+	order.Items = append(order.Items, model.Items{Name: "Sneakers", Brand: "Nike"})
+
+	rr := getOrder(t, order, "/order/fmt-2", "text/csv")
+
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("expected csv content type, got %q", ct)
+	}
+	rows, err := csv.NewReader(rr.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("bad csv: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected header and 2 item rows, got %d rows", len(rows))
+	}
+	if rows[1][0] != "fmt-2" || rows[2][0] != "fmt-2" {
+		t.Fatalf("expected order_uid in every row, got %v", rows)
+	}
+}
+
+func TestGetOrderByPath_Msgpack(t *testing.T) {
+	rr := getOrder(t, validOrder("fmt-3"), "/order/fmt-3", "application/msgpack")
+
+	if ct := rr.Header().Get("Content-Type"); ct != mediaMsgpack {
+		t.Fatalf("expected msgpack content type, got %q", ct)
+	}
+
+	var got map[string]any
+	if err := msgpack.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad msgpack: %v", err)
+	}
+	if got["order_uid"] != "fmt-3" {
+		t.Fatalf("expected order_uid key from json tags, got %v", got["order_uid"])
+	}
+}
+
+func TestGetOrderByPath_Protobuf(t *testing.T) {
+	rr := getOrder(t, validOrder("fmt-4"), "/order/fmt-4", "application/x-protobuf")
+
+	if ct := rr.Header().Get("Content-Type"); ct != mediaProtobuf {
+		t.Fatalf("expected protobuf content type, got %q", ct)
+	}
+
+	got, err := orderpb.Unmarshal(rr.Body.Bytes())
+	if err != nil {
+		t.Fatalf("bad protobuf: %v", err)
+	}
+	if got.OrderUID != "fmt-4" || got.Delivery.City != "Kiryat Mozkin" {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+}
+
+func TestRespond_NotAcceptable(t *testing.T) {
+	rr := getOrder(t, validOrder("fmt-5"), "/order/fmt-5", "image/png")
+
+	if rr.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected status 406, got %d", rr.Code)
+	}
+}
+
+func TestRespond_CSVUnsupportedForNonOrders(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	req.Header.Set("Accept", "text/csv")
+	rr := httptest.NewRecorder()
+	s.respond(rr, req, http.StatusOK, batchResponse{})
+
+	if rr.Code != http.StatusNotAcceptable {
+		t.Fatalf("expected status 406, got %d", rr.Code)
+	}
+}
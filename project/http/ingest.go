@@ -0,0 +1,159 @@
+package http
+
+import (
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/pipeline"
+	"awesomeProject3/project/validation"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+const (
+	maxIngestBody  = 10 << 20
+	maxIngestBatch = 1000
+)
+
+type ingestResult struct {
+	OrderUID string                  `json:"order_uid"`
+	Status   string                  `json:"status"`
+	Errors   []validation.FieldError `json:"errors,omitempty"`
+}
+
+type batchResponse struct {
+	Accepted int            `json:"accepted"`
+	Rejected int            `json:"rejected"`
+	Results  []ingestResult `json:"results"`
+}
+
+const (
+	statusCreated  = "created"
+	statusAccepted = "accepted"
+	statusInvalid  = "invalid"
+	statusFailed   = "failed"
+	// statusDuplicate means an order with the same order_uid is already
+	// stored; the posted body is discarded.
+	statusDuplicate = "duplicate"
+)
+
+func (s *Server) CreateOrders(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIngestBody))
+	if err != nil {
+		s.problem(w, r, http.StatusRequestEntityTooLarge, "Request body is too large")
+		return
+	}
+
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		s.problem(w, r, http.StatusBadRequest, "Request body is empty")
+		return
+	}
+
+	if body[0] == '[' {
+		s.createBatch(w, r, body)
+		return
+	}
+
+	var order model.Order
+	if err := json.Unmarshal(body, &order); err != nil {
+		s.problem(w, r, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+	if !canSee(r, order) {
+		s.problem(w, r, http.StatusForbidden, "Orders of other customers can't be created")
+		return
+	}
+
+	res := s.ingest(r.Context(), []model.Order{order})[0]
+
+	status := http.StatusCreated
+	switch res.Status {
+	case statusAccepted:
+		status = http.StatusAccepted
+	case statusInvalid:
+		status = http.StatusUnprocessableEntity
+	case statusDuplicate:
+		status = http.StatusConflict
+	case statusFailed:
+		status = http.StatusInternalServerError
+	}
+
+	s.respond(w, r, status, res)
+}
+
+func (s *Server) createBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	var orders []model.Order
+	if err := json.Unmarshal(body, &orders); err != nil {
+		s.problem(w, r, http.StatusBadRequest, "Invalid JSON: "+err.Error())
+		return
+	}
+	if len(orders) == 0 {
+		s.problem(w, r, http.StatusBadRequest, "Batch is empty")
+		return
+	}
+	if len(orders) > maxIngestBatch {
+		s.problem(w, r, http.StatusRequestEntityTooLarge, "Batch is too large")
+		return
+	}
+	if len(visibleOrders(r, orders)) != len(orders) {
+		s.problem(w, r, http.StatusForbidden, "Orders of other customers can't be created")
+		return
+	}
+
+	resp := batchResponse{Results: s.ingest(r.Context(), orders)}
+	for _, res := range resp.Results {
+		if res.Status == statusCreated || res.Status == statusAccepted {
+			resp.Accepted++
+		} else {
+			resp.Rejected++
+		}
+	}
+
+	s.respond(w, r, http.StatusOK, resp)
+}
+
+// ingest runs orders through the same stages as Consumer.HandleMessage, or
+// validates them and hands them to the Kafka publisher so they take the
+// consumer path. Results keep the order of the input.
+func (s *Server) ingest(ctx context.Context, orders []model.Order) []ingestResult {
+	stages := []pipeline.Stage{pipeline.Validate(s.validateFn)}
+	if s.Publisher != nil {
+		stages = append(stages, pipeline.Publish(s.Publisher))
+	} else {
+		stages = append(stages, pipeline.Persist(s.DB), pipeline.Cache(s.Cache))
+	}
+
+	envs := make([]*pipeline.Envelope, len(orders))
+	for i, o := range orders {
+		envs[i] = &pipeline.Envelope{Order: o, Source: pipeline.SourceHTTP}
+	}
+
+	errs := pipeline.New(stages...).RunBatch(ctx, envs)
+
+	results := make([]ingestResult, len(orders))
+	for i, err := range errs {
+		results[i].OrderUID = orders[i].OrderUID
+		switch {
+		case err == nil && s.Publisher != nil:
+			results[i].Status = statusAccepted
+		case err == nil:
+			results[i].Status = statusCreated
+		case errors.Is(err, database.ErrExists):
+			results[i].Status = statusDuplicate
+		case pipeline.IsRejected(err):
+			results[i].Status = statusInvalid
+			results[i].Errors = validation.Describe(err)
+		default:
+			log.Printf("Can't ingest order %s: %v", orders[i].OrderUID, err)
+			results[i].Status = statusFailed
+			results[i].Errors = []validation.FieldError{{Message: "can't " + pipeline.FailedStage(err) + " order"}}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,185 @@
+package http
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type mockPublisher struct {
+	calls  int
+	orders []model.Order
+	err    error
+}
+
+func (p *mockPublisher) Publish(ctx context.Context, orders ...model.Order) error {
+	p.calls++
+	p.orders = append(p.orders, orders...)
+	return p.err
+}
+
+func validOrder(uid string) model.Order {
+	return model.Order{
+		OrderUID:    uid,
+		TrackNumber: "TRACK",
+		Entry:       "WBIL",
+		Delivery: model.Delivery{
+			Name: "John Doe", Phone: "+79720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "john@example.com",
+		},
+		Payment: model.Payment{
+			Transaction: uid, Currency: "USD", Provider: "wbpay", Amount: 1817,
+			PaymentDT: 1637907727, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 317,
+		},
+		Items: []model.Items{{
+			ChrtID: 9934930, TrackNumber: "TRACK", Price: 453, Rid: "ab4219087a764ae0btest",
+			Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202,
+		}},
+		CustomerID:  "test",
+		DateCreated: "2021-11-26T06:22:19Z",
+	}
+}
+
+func postOrders(s *Server, body any) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader(data))
+	rr := httptest.NewRecorder()
+	s.CreateOrders(rr, req)
+	return rr
+}
+
+func TestCreateOrders_Single_InsertsAndCaches(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+	s := NewServer(db, c)
+
+	rr := postOrders(s, validOrder("new-1"))
+
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("expected status 201, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+	if db.InsertCalls != 1 || db.LastInsert.OrderUID != "new-1" {
+		t.Fatalf("expected db.InsertOrder(new-1), got calls=%d uid=%q", db.InsertCalls, db.LastInsert.OrderUID)
+	}
+	if c.SetCalls != 1 || c.LastSetUID != "new-1" {
+		t.Fatalf("expected cache.Set(new-1), got calls=%d uid=%q", c.SetCalls, c.LastSetUID)
+	}
+}
+
+func TestCreateOrders_Single_Duplicate(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+	s := NewServer(db, c)
+	db.InsertOrderFunc = func(order model.Order) error { return database.ErrExists }
+
+	rr := postOrders(s, validOrder("old-1"))
+
+	if rr.Code != http.StatusConflict {
+		t.Fatalf("expected status 409, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+	var res ingestResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &res); err != nil || res.Status != statusDuplicate {
+		t.Fatalf("expected duplicate result, got %s", rr.Body.String())
+	}
+	if c.SetCalls != 0 {
+		t.Fatalf("expected cache.Set not called for a duplicate, got %d", c.SetCalls)
+	}
+}
+
+func TestCreateOrders_Single_ValidationErrors(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+	s := NewServer(db, c)
+
+	order := validOrder("bad-1")
+	order.Delivery.Email = "not-an-email"
+	order.Payment.Currency = ""
+
+	rr := postOrders(s, order)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected status 422, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+	if db.InsertCalls != 0 || c.SetCalls != 0 {
+		t.Fatalf("expected no insert and no cache set for invalid order")
+	}
+
+	var got ingestResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	fields := map[string]string{}
+	for _, fe := range got.Errors {
+		fields[fe.Field] = fe.Rule
+	}
+	if fields["delivery.email"] != "email" || fields["payment.currency"] != "required" {
+		t.Fatalf("unexpected field errors: %+v", got.Errors)
+	}
+}
+
+func TestCreateOrders_Batch_Mixed(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+	s := NewServer(db, c)
+
+	invalid := validOrder("bad-2")
+	invalid.Items = nil
+
+	rr := postOrders(s, []model.Order{validOrder("new-2"), invalid, validOrder("new-3")})
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+
+	var got batchResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if got.Accepted != 2 || got.Rejected != 1 {
+		t.Fatalf("expected 2 accepted and 1 rejected, got %+v", got)
+	}
+	if got.Results[1].OrderUID != "bad-2" || got.Results[1].Status != statusInvalid {
+		t.Fatalf("expected second result to be invalid, got %+v", got.Results[1])
+	}
+	if db.InsertCalls != 2 {
+		t.Fatalf("expected 2 inserts, got %d", db.InsertCalls)
+	}
+}
+
+func TestCreateOrders_PublisherMode_SkipsDB(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+	pub := &mockPublisher{}
+	s := NewServer(db, c)
+	s.Publisher = pub
+
+	rr := postOrders(s, validOrder("new-4"))
+
+	if rr.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+	if pub.calls != 1 || len(pub.orders) != 1 || pub.orders[0].OrderUID != "new-4" {
+		t.Fatalf("expected order published once, got calls=%d orders=%d", pub.calls, len(pub.orders))
+	}
+	if db.InsertCalls != 0 || c.SetCalls != 0 {
+		t.Fatalf("expected db and cache untouched in publisher mode")
+	}
+}
+
+func TestCreateOrders_InvalidJSON(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+
+	req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader([]byte("{not-json")))
+	rr := httptest.NewRecorder()
+	s.CreateOrders(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rr.Code)
+	}
+}
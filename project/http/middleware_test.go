@@ -0,0 +1,73 @@
+package http
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func serveMiddleware(s *Server, h http.HandlerFunc, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/x", nil)
+	for k, v := range header {
+		req.Header.Set(k, v[0])
+	}
+	rr := httptest.NewRecorder()
+	s.middleware(h).ServeHTTP(rr, req)
+	return rr
+}
+
+func TestMiddleware_RecoversPanic(t *testing.T) {
+	var logs bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	defer log.SetOutput(prev)
+
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	rr := serveMiddleware(s, func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+		http.Header{requestIDHeader: {"req-1"}})
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d", rr.Code)
+	}
+	if rr.Header().Get("Content-Type") != mediaProblem || rr.Header().Get(requestIDHeader) != "req-1" {
+		t.Fatalf("expected problem with request id, got %v", rr.Header())
+	}
+	if !strings.Contains(logs.String(), "boom") {
+		t.Fatalf("expected panic to be logged, got %q", logs.String())
+	}
+	if !strings.Contains(logs.String(), "status=500") || !strings.Contains(logs.String(), "request_id=req-1") {
+		t.Fatalf("expected access log line, got %q", logs.String())
+	}
+}
+
+func TestMiddleware_RequestTimeout(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	s.Timeouts.Request = 20 * time.Millisecond
+
+	rr := serveMiddleware(s, func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.Write([]byte("late"))
+	}, nil)
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+	if strings.Contains(rr.Body.String(), "late") {
+		t.Fatalf("expected late write to be dropped, got %q", rr.Body.String())
+	}
+
+	rr = serveMiddleware(s, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Test", "1")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("ok"))
+	}, nil)
+	if rr.Code != http.StatusCreated || rr.Header().Get("X-Test") != "1" || rr.Body.String() != "ok" {
+		t.Fatalf("expected handler response to pass through, got %d %v %q", rr.Code, rr.Header(), rr.Body.String())
+	}
+}
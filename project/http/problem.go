@@ -0,0 +1,74 @@
+package http
+
+import (
+	"awesomeProject3/project/database"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const mediaProblem = "application/problem+json"
+
+// Problem is an RFC 9457 problem details body.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func (s *Server) problem(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	p := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  r.URL.Path,
+		RequestID: RequestID(r.Context()),
+	}
+
+	w.Header().Set("Content-Type", mediaProblem)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(p)
+}
+
+// dbError answers a failed database call. Missing rows are reported as
+// notFound; everything else is logged and hidden behind a generic detail.
+func (s *Server) dbError(w http.ResponseWriter, r *http.Request, err error, notFound string) {
+	status := dbErrorStatus(err)
+	if status == http.StatusNotFound {
+		s.problem(w, r, status, notFound)
+		return
+	}
+
+	log.Printf("Can't serve %s %s request_id=%s: %v", r.Method, r.URL.Path, RequestID(r.Context()), err)
+
+	detail := "Internal error"
+	switch status {
+	case http.StatusServiceUnavailable:
+		detail = "Database is unavailable"
+	case http.StatusGatewayTimeout:
+		detail = "Database did not respond in time"
+	}
+	s.problem(w, r, status, detail)
+}
+
+func dbErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, context.DeadlineExceeded), pgconn.Timeout(err):
+		return http.StatusGatewayTimeout
+	case database.IsUnavailable(err):
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
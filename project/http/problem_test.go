@@ -0,0 +1,83 @@
+package http
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/puddle/v2"
+)
+
+func TestGetOrderByPath_DBErrorStatus(t *testing.T) {
+	cases := []struct {
+		err  error
+		want int
+	}{
+		{pgx.ErrNoRows, http.StatusNotFound},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), http.StatusGatewayTimeout},
+		{puddle.ErrClosedPool, http.StatusServiceUnavailable},
+		{errors.New("syntax error"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range cases {
+		db := &database.MockDB{GetOrderFunc: func(string) (model.Order, error) { return model.Order{}, tc.err }}
+		s := NewServer(db, &cache.MockCache{})
+
+		req := httptest.NewRequest(http.MethodGet, "/order/o-1", nil)
+		req = mux.SetURLVars(req, map[string]string{"order_uid": "o-1"})
+		rr := httptest.NewRecorder()
+		s.GetOrderByPath(rr, req)
+
+		if rr.Code != tc.want {
+			t.Fatalf("%v: expected status %d, got %d", tc.err, tc.want, rr.Code)
+		}
+	}
+}
+
+func TestProblem_Body(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	h := withRequestID(http.HandlerFunc(s.FindOrders))
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	req.Header.Set("X-Request-ID", "req-42")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if ct := rr.Header().Get("Content-Type"); ct != mediaProblem {
+		t.Fatalf("expected problem content type, got %q", ct)
+	}
+	if got := rr.Header().Get("X-Request-ID"); got != "req-42" {
+		t.Fatalf("expected request id to be echoed, got %q", got)
+	}
+
+	var p Problem
+	if err := json.Unmarshal(rr.Body.Bytes(), &p); err != nil {
+		t.Fatalf("bad problem body: %v", err)
+	}
+	if p.Status != http.StatusBadRequest || p.Title != "Bad Request" || p.Instance != "/orders" || p.RequestID != "req-42" {
+		t.Fatalf("unexpected problem: %+v", p)
+	}
+}
+
+func TestWithRequestID_Generates(t *testing.T) {
+	var seen string
+	h := withRequestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if seen == "" || rr.Header().Get("X-Request-ID") != seen {
+		t.Fatalf("expected generated request id in context and header, got %q / %q", seen, rr.Header().Get("X-Request-ID"))
+	}
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"awesomeProject3/project/auth"
+	"awesomeProject3/project/ratelimit"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRoute is the Server.RateLimits key applied to routes without their
+// own limit. Routes with neither are not limited.
+const DefaultRoute = "*"
+
+// AuthFailuresRoute is the Server.RateLimits key for failed authentication
+// attempts, counted per IP across all routes. It falls back to DefaultRoute.
+const AuthFailuresRoute = "auth.failures"
+
+// rateLimit limits next per client with the limit configured for route. It
+// runs after authorize, so authenticated callers are limited per principal
+// and everyone else per IP. Requests that fail authentication never get
+// here; authorize limits those with AuthFailuresRoute.
+func (s *Server) rateLimit(route string, next http.Handler) http.Handler {
+	limit, ok := s.routeLimit(route)
+	if !ok {
+		return next
+	}
+
+	limiter := s.newLimiter(limit)
+	policy := fmt.Sprintf("%d;w=%d", limit.Burst, int(limit.Window().Seconds()))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		res := limiter.Allow(s.clientKey(r))
+		setRateLimitHeaders(w, policy, res)
+		if !res.Allowed {
+			s.tooManyRequests(w, r, res)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) routeLimit(route string) (ratelimit.Limit, bool) {
+	limit, ok := s.RateLimits[route]
+	if !ok {
+		limit, ok = s.RateLimits[DefaultRoute]
+	}
+	return limit, ok
+}
+
+// authFailureLimiter is shared by every route so that an IP cannot spread
+// credential guessing across them. It is nil when no limit applies.
+func (s *Server) authFailureLimiter() *ratelimit.Limiter {
+	s.authFailOnce.Do(func() {
+		if limit, ok := s.routeLimit(AuthFailuresRoute); ok {
+			s.authFailures = s.newLimiter(limit)
+		}
+	})
+	return s.authFailures
+}
+
+// newLimiter returns a limiter that Shutdown closes.
+func (s *Server) newLimiter(limit ratelimit.Limit) *ratelimit.Limiter {
+	l := ratelimit.New(limit)
+	s.mu.Lock()
+	s.limiters = append(s.limiters, l)
+	s.mu.Unlock()
+	return l
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, policy string, res ratelimit.Result) {
+	h := w.Header()
+	h.Set("RateLimit-Policy", policy)
+	h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+	h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	h.Set("RateLimit-Reset", ceilSeconds(res.Reset))
+}
+
+func (s *Server) tooManyRequests(w http.ResponseWriter, r *http.Request, res ratelimit.Result) {
+	w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+	s.problem(w, r, http.StatusTooManyRequests, "Rate limit exceeded")
+}
+
+func (s *Server) clientKey(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok && p.Subject != "" {
+		return "principal:" + p.Subject
+	}
+	return "ip:" + s.clientIP(r)
+}
+
+// clientIP trusts X-Forwarded-For only when TrustProxy is set, since clients
+// can send any value.
+func (s *Server) clientIP(r *http.Request) string {
+	if s.TrustProxy {
+		if xff := r.Header.Get("X-Forwarded-For"); xff != "" {
+			first, _, _ := strings.Cut(xff, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"awesomeProject3/project/auth"
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func limitedGet(h http.Handler, remoteAddr string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	for k, v := range header {
+		req.Header.Set(k, v[0])
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestRateLimit_PerIP(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	s.RateLimits = map[string]ratelimit.Limit{"search": ratelimit.Per(2, time.Minute)}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	h := s.route("search", auth.ScopeRead, ok)
+
+	for i := 0; i < 2; i++ {
+		if rr := limitedGet(h, "10.0.0.1:1234", nil); rr.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d", rr.Code)
+		}
+	}
+	rr := limitedGet(h, "10.0.0.1:5678", nil)
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected Retry-After 30, got %q", rr.Header().Get("Retry-After"))
+	}
+	if rr.Header().Get("RateLimit-Limit") != "2" || rr.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected RateLimit headers, got %v", rr.Header())
+	}
+	if rr.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Fatalf("expected policy 2;w=60, got %q", rr.Header().Get("RateLimit-Policy"))
+	}
+	if rr := limitedGet(h, "10.0.0.2:1234", nil); rr.Code != http.StatusOK {
+		t.Fatalf("expected other IP to be allowed, got %d", rr.Code)
+	}
+}
+
+func TestRateLimit_DefaultRouteAndProxy(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	if rr := limitedGet(s.route("order", auth.ScopeRead, ok), "10.0.0.1:1", nil); rr.Header().Get("RateLimit-Limit") != "" {
+		t.Fatalf("expected no limit without config")
+	}
+
+	s.RateLimits = map[string]ratelimit.Limit{DefaultRoute: ratelimit.Per(1, time.Second)}
+	s.TrustProxy = true
+	h := s.route("order", auth.ScopeRead, ok)
+	fwd := func(ip string) http.Header { return http.Header{"X-Forwarded-For": {ip + ", 10.0.0.1"}} }
+
+	if rr := limitedGet(h, "10.0.0.1:1", fwd("1.1.1.1")); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if rr := limitedGet(h, "10.0.0.1:1", fwd("2.2.2.2")); rr.Code != http.StatusOK {
+		t.Fatalf("expected forwarded client to have its own bucket, got %d", rr.Code)
+	}
+	if rr := limitedGet(h, "10.0.0.1:1", fwd("1.1.1.1")); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %d", rr.Code)
+	}
+}
+
+func TestRateLimit_PerPrincipal(t *testing.T) {
+	s := newAuthServer(&database.MockDB{})
+	s.RateLimits = map[string]ratelimit.Limit{DefaultRoute: ratelimit.Per(1, time.Minute)}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	h := s.route("order", auth.ScopeRead, ok)
+
+	reader := http.Header{"X-Api-Key": {"reader"}}
+	if rr := limitedGet(h, "10.0.0.1:1", reader); rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if rr := limitedGet(h, "10.0.0.2:1", reader); rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected key to be limited across IPs, got %d", rr.Code)
+	}
+	if rr := limitedGet(h, "10.0.0.1:1", http.Header{"X-Api-Key": {"customer"}}); rr.Code != http.StatusOK {
+		t.Fatalf("expected other key to be allowed, got %d", rr.Code)
+	}
+}
+
+func TestRateLimit_FailedAuthPerIP(t *testing.T) {
+	s := newAuthServer(&database.MockDB{})
+	s.RateLimits = map[string]ratelimit.Limit{AuthFailuresRoute: ratelimit.Per(2, time.Minute)}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	order := s.route("order", auth.ScopeRead, ok)
+	search := s.route("orders.search", auth.ScopeRead, ok)
+
+	bad := http.Header{"X-Api-Key": {"guess"}}
+	if rr := limitedGet(order, "10.0.0.1:1", bad); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+	if rr := limitedGet(search, "10.0.0.1:2", bad); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+	rr := limitedGet(order, "10.0.0.1:3", http.Header{"X-Api-Key": {"reader"}})
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 after repeated 401s, got %d", rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "30" {
+		t.Fatalf("expected Retry-After 30, got %q", rr.Header().Get("Retry-After"))
+	}
+	if rr := limitedGet(order, "10.0.0.2:1", http.Header{"X-Api-Key": {"reader"}}); rr.Code != http.StatusOK {
+		t.Fatalf("expected other IP to be allowed, got %d", rr.Code)
+	}
+}
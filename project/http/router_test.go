@@ -0,0 +1,77 @@
+package http
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler_Routes(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	ts := httptest.NewServer(s.Handler())
+	defer ts.Close()
+
+	order, _ := json.Marshal(validOrder("o-1"))
+	cases := []struct {
+		method, path string
+		body         []byte
+		want         int
+	}{
+		{http.MethodGet, "/order/o-1", nil, http.StatusOK},
+		{http.MethodGet, "/order/by-track/TRACK", nil, http.StatusOK},
+		{http.MethodGet, "/orders?transaction=o-1", nil, http.StatusOK},
+		{http.MethodGet, "/orders", nil, http.StatusBadRequest},
+		{http.MethodPost, "/orders", order, http.StatusCreated},
+		{http.MethodGet, "/orders/search?q=nike", nil, http.StatusOK},
+		{http.MethodGet, "/customers/c-1/orders", nil, http.StatusOK},
+		{http.MethodGet, "/debug/vars", nil, http.StatusOK},
+		{http.MethodDelete, "/order/o-1", nil, http.StatusMethodNotAllowed},
+		{http.MethodPut, "/orders", nil, http.StatusMethodNotAllowed},
+		{http.MethodPost, "/orders/search", nil, http.StatusMethodNotAllowed},
+		{http.MethodPost, "/customers/c-1/orders", nil, http.StatusMethodNotAllowed},
+	}
+	for _, c := range cases {
+		req, _ := http.NewRequest(c.method, ts.URL+c.path, bytes.NewReader(c.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s: unexpected error: %v", c.method, c.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != c.want {
+			t.Fatalf("%s %s: expected %d, got %d", c.method, c.path, c.want, resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusMethodNotAllowed && resp.Header.Get("Content-Type") != mediaProblem {
+			t.Fatalf("%s %s: expected problem details, got %q", c.method, c.path, resp.Header.Get("Content-Type"))
+		}
+		if resp.Header.Get(requestIDHeader) == "" {
+			t.Fatalf("%s %s: expected request id header", c.method, c.path)
+		}
+	}
+}
+
+func TestRun_StopsOnContextCancel(t *testing.T) {
+	s := NewServer(&database.MockDB{}, &cache.MockCache{})
+	s.Addr = "127.0.0.1:0"
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- s.Run(ctx) }()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("expected Run to return after cancel")
+	}
+}
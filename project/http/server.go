@@ -1,30 +1,69 @@
 package http
 
 import (
+	"awesomeProject3/project/auth"
 	"awesomeProject3/project/cache"
 	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/pipeline"
+	"awesomeProject3/project/ratelimit"
+	"awesomeProject3/project/validation"
 	"context"
-	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
 	"log"
 	"net/http"
-	"os"
-	"os/signal"
-	"syscall"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gorilla/mux"
 )
 
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
 type Server struct {
-	DB     database.DB
-	Cache  cache.CC
-	server *http.Server
+	Addr            string
+	ShutdownTimeout time.Duration
+	DB              database.DB
+	Cache           cache.CC
+	Publisher       pipeline.Publisher
+	Auth            *auth.Auth
+	MaskPII         bool
+	RateLimits      map[string]ratelimit.Limit
+	TrustProxy      bool
+	Encoders        *Encoders
+	CORS            CORSConfig
+	Timeouts        Timeouts
+	Compress        bool
+	TLS             TLSConfig
+	HTTP2           bool
+	mu              sync.Mutex
+	server          *http.Server
+	authFailures    *ratelimit.Limiter
+	limiters        []*ratelimit.Limiter
+	authFailOnce    sync.Once
+	validateFn      func(*model.Order) error
 }
 
 func NewServer(db database.DB, c cache.CC) *Server {
 	return &Server{
-		DB:    db,
-		Cache: c,
+		Addr:            ":8080",
+		ShutdownTimeout: 5 * time.Second,
+		DB:              db,
+		Cache:           c,
+		Encoders:        NewEncoders(),
+		CORS:            DefaultCORSConfig(),
+		Timeouts:        DefaultTimeouts(),
+		Compress:        true,
+		HTTP2:           true,
+		validateFn:      validation.ValidateOrder,
 	}
 }
 
@@ -33,49 +72,235 @@ func (s *Server) GetOrderByPath(w http.ResponseWriter, r *http.Request) {
 	orderID := vars["order_uid"]
 
 	if orderID == "" {
-		http.Error(w, "Order ID is required", http.StatusBadRequest)
+		s.problem(w, r, http.StatusBadRequest, "Order ID is required")
+		return
+	}
+
+	entry, err := s.orderEntry(orderID)
+	if err != nil {
+		s.dbError(w, r, err, "Order not found")
+		return
+	}
+	if !canSee(r, entry.Order) {
+		s.problem(w, r, http.StatusNotFound, "Order not found")
+		return
+	}
+
+	s.respondEntry(w, r, entry)
+}
+
+func (s *Server) GetOrderByTrack(w http.ResponseWriter, r *http.Request) {
+	track := mux.Vars(r)["track"]
+
+	if track == "" {
+		s.problem(w, r, http.StatusBadRequest, "Track number is required")
+		return
+	}
+
+	order, err := s.DB.GetOrderByTrack(track)
+	if err != nil {
+		s.dbError(w, r, err, "Order not found")
 		return
 	}
+	if !canSee(r, order) {
+		s.problem(w, r, http.StatusNotFound, "Order not found")
+		return
+	}
+
+	s.respondEntry(w, r, cache.Entry{Order: order, ETag: cache.ETag(order), Modified: order.CreatedAt})
+}
+
+func (s *Server) FindOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := database.OrderFilter{
+		Transaction: q.Get("transaction"),
+		Email:       q.Get("email"),
+		Phone:       q.Get("phone"),
+	}
 
-	if order, ok := s.Cache.Get(orderID); ok {
-		w.Header().Set("Content-Type", "application/json")
-		s.addCORSHeaders(w)
-		_ = json.NewEncoder(w).Encode(order)
+	if filter.Empty() {
+		s.problem(w, r, http.StatusBadRequest, "One of transaction, email or phone is required")
 		return
 	}
 
-	order, err := s.DB.GetOrder(orderID)
+	orders, err := s.DB.FindOrders(filter)
 	if err != nil {
-		http.Error(w, "Order not found", http.StatusNotFound)
+		s.dbError(w, r, err, "No orders found")
 		return
 	}
 
-	s.Cache.Set(orderID, order)
-	w.Header().Set("Content-Type", "application/json")
-	s.addCORSHeaders(w)
-	_ = json.NewEncoder(w).Encode(order)
+	s.respond(w, r, http.StatusOK, visibleOrders(r, orders))
+}
+
+type searchResponse struct {
+	Query   string                  `json:"query"`
+	Total   int                     `json:"total"`
+	Limit   int                     `json:"limit"`
+	Offset  int                     `json:"offset"`
+	Results []database.SearchResult `json:"results"`
+}
+
+func (r searchResponse) listOrders() []model.Order {
+	orders := make([]model.Order, len(r.Results))
+	for i, res := range r.Results {
+		orders[i] = res.Order
+	}
+	return orders
+}
+
+func (s *Server) SearchOrders(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	query := strings.TrimSpace(q.Get("q"))
+
+	if query == "" {
+		s.problem(w, r, http.StatusBadRequest, "Search query is required")
+		return
+	}
+	if restrictedTo(r) != "" {
+		s.problem(w, r, http.StatusForbidden, "Search is not available to customer credentials")
+		return
+	}
+
+	limit, offset, ok := s.page(w, r)
+	if !ok {
+		return
+	}
+
+	results, total, err := s.DB.SearchOrders(query, limit, offset)
+	if err != nil {
+		s.dbError(w, r, err, "No orders found")
+		return
+	}
+
+	s.respond(w, r, http.StatusOK, searchResponse{
+		Query:   query,
+		Total:   total,
+		Limit:   limit,
+		Offset:  offset,
+		Results: results,
+	})
+}
+
+// page reads the limit and offset query parameters. It writes a problem
+// response and returns false when they are invalid.
+func (s *Server) page(w http.ResponseWriter, r *http.Request) (limit, offset int, ok bool) {
+	q := r.URL.Query()
+	limit, err := queryInt(q, "limit", defaultPageLimit)
+	if err != nil || limit <= 0 || limit > maxPageLimit {
+		s.problem(w, r, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxPageLimit))
+		return 0, 0, false
+	}
+	offset, err = queryInt(q, "offset", 0)
+	if err != nil || offset < 0 {
+		s.problem(w, r, http.StatusBadRequest, "offset must be a non-negative integer")
+		return 0, 0, false
+	}
+	return limit, offset, true
+}
+
+func queryInt(q url.Values, key string, def int) (int, error) {
+	v := q.Get(key)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+type customerOrdersResponse struct {
+	CustomerID string           `json:"customer_id"`
+	OrderCount int              `json:"order_count"`
+	Totals     map[string]int64 `json:"totals"`
+	Limit      int              `json:"limit"`
+	Offset     int              `json:"offset"`
+	Orders     []model.Order    `json:"orders"`
+}
+
+func (r customerOrdersResponse) listOrders() []model.Order { return r.Orders }
+
+func (s *Server) GetCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customer_id"]
+
+	if customerID == "" {
+		s.problem(w, r, http.StatusBadRequest, "Customer ID is required")
+		return
+	}
+	if c := restrictedTo(r); c != "" && c != customerID {
+		s.problem(w, r, http.StatusForbidden, "Orders of other customers are not available")
+		return
+	}
+
+	limit, offset, ok := s.page(w, r)
+	if !ok {
+		return
+	}
+
+	res, err := s.DB.GetCustomerOrders(customerID, limit, offset)
+	if err != nil {
+		s.dbError(w, r, err, "No orders found")
+		return
+	}
+
+	resp := customerOrdersResponse{
+		CustomerID: customerID,
+		OrderCount: res.Count,
+		Totals:     res.Totals,
+		Limit:      limit,
+		Offset:     offset,
+		Orders:     res.Orders,
+	}
+	if resp.Totals == nil {
+		resp.Totals = map[string]int64{}
+	}
+	if resp.Orders == nil {
+		resp.Orders = []model.Order{}
+	}
+
+	s.respond(w, r, http.StatusOK, resp)
 }
 
 func (s *Server) Index(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "web/index.html")
 }
 
-func (s *Server) addCORSHeaders(w http.ResponseWriter) {
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+// route wraps an API handler with authorization and the route's rate limit.
+func (s *Server) route(name, scope string, h http.HandlerFunc) http.Handler {
+	return s.authorize(scope, s.rateLimit(name, h).ServeHTTP)
+}
+
+// Handler returns the router wrapped in the middleware chain.
+func (s *Server) Handler() http.Handler {
+	return s.middleware(s.router())
 }
 
-func (s *Server) Run(addr string) error {
+func (s *Server) router() *mux.Router {
 	r := mux.NewRouter()
+	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.problem(w, r, http.StatusNotFound, "Not found")
+	})
+	r.MethodNotAllowedHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.problem(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+	})
 
-	r.HandleFunc("/order/{order_uid}", s.GetOrderByPath)
+	r.Handle("/order/by-track/{track}", s.route("order.by-track", auth.ScopeRead, s.GetOrderByTrack)).Methods("GET")
+	r.Handle("/order/{order_uid}", s.route("order", auth.ScopeRead, s.GetOrderByPath)).Methods("GET")
+	r.Handle("/orders", s.route("orders.find", auth.ScopeRead, s.FindOrders)).Methods("GET")
+	r.Handle("/orders", s.route("orders.create", auth.ScopeWrite, s.CreateOrders)).Methods("POST")
+	r.Handle("/orders/search", s.route("orders.search", auth.ScopeRead, s.SearchOrders)).Methods("GET")
+	r.Handle("/customers/{customer_id}/orders", s.route("customer.orders", auth.ScopeRead, s.GetCustomerOrders)).Methods("GET")
+	r.Handle("/debug/vars", s.route("debug.vars", auth.ScopeAdmin, s.requireClientCert(expvar.Handler().ServeHTTP))).Methods("GET")
 	r.HandleFunc("/", s.Index).Methods("GET")
 
 	fs := http.FileServer(http.Dir("./web"))
-	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", fs))
+	r.PathPrefix("/static/").Handler(http.StripPrefix("/static/", fs)).Methods("GET")
+
+	r.PathPrefix("/").HandlerFunc(s.Index).Methods("GET")
+	return r
+}
 
-	r.PathPrefix("/").HandlerFunc(s.Index)
+// Run serves until ctx is cancelled and then shuts down gracefully, waiting
+// up to ShutdownTimeout for in-flight requests.
+func (s *Server) Run(ctx context.Context) error {
+	r := s.router()
 
 	log.Println("All routes registered:")
 	_ = r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
@@ -85,26 +310,70 @@ func (s *Server) Run(addr string) error {
 		return nil
 	})
 
-	s.server = &http.Server{
-		Addr:    addr,
-		Handler: r,
+	srv := &http.Server{
+		Addr:              s.Addr,
+		Handler:           s.middleware(r),
+		ReadHeaderTimeout: s.Timeouts.ReadHeader,
+		ReadTimeout:       s.Timeouts.Read,
+		WriteTimeout:      s.Timeouts.Write,
+		IdleTimeout:       s.Timeouts.Idle,
 	}
+	srv.Protocols = new(http.Protocols)
+	srv.Protocols.SetHTTP1(true)
+	srv.Protocols.SetHTTP2(s.HTTP2)
 
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	if s.TLS.Enabled() {
+		cfg, err := s.TLS.serverConfig(ctx)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = cfg
+	}
 
+	s.mu.Lock()
+	s.server = srv
+	s.mu.Unlock()
+
+	errc := make(chan error, 1)
 	go func() {
-		log.Printf("Listening on %s\n", addr)
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server error: %v", err)
+		if srv.TLSConfig != nil {
+			log.Printf("Listening on %s (TLS)\n", s.Addr)
+			errc <- srv.ListenAndServeTLS("", "")
+			return
 		}
+		log.Printf("Listening on %s\n", s.Addr)
+		errc <- srv.ListenAndServe()
 	}()
 
-	<-stop
-	log.Println("Shutting down gracefully...")
+	select {
+	case err := <-errc:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	log.Println("Shutting down gracefully...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.ShutdownTimeout)
 	defer cancel()
 
-	return s.server.Shutdown(ctx)
+	return s.Shutdown(shutdownCtx)
+}
+
+// Shutdown stops a running server, waiting for in-flight requests until ctx
+// is done, and stops the rate limiters' janitors.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	srv := s.server
+	for _, l := range s.limiters {
+		l.Close()
+	}
+	s.limiters = nil
+	s.mu.Unlock()
+
+	if srv == nil {
+		return nil
+	}
+	return srv.Shutdown(ctx)
 }
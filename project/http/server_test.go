@@ -10,6 +10,7 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgx/v5"
 )
 
 func TestGetOrderByPath_CacheHit_DBNotCalled(t *testing.T) {
@@ -108,3 +109,225 @@ func TestGetOrderByPath_CacheMiss_DBCalled_ThenCacheSet(t *testing.T) {
 		t.Fatalf("expected OrderUID %q, got %q", want.OrderUID, got.OrderUID)
 	}
 }
+
+func TestGetOrderByTrack_Found(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+
+	db.GetByTrackFunc = func(track string) (model.Order, error) {
+		return model.Order{OrderUID: "order-3", TrackNumber: track}, nil
+	}
+
+	s := NewServer(db, c)
+
+	req := httptest.NewRequest(http.MethodGet, "/order/by-track/TRACK3", nil)
+	req = mux.SetURLVars(req, map[string]string{"track": "TRACK3"})
+	rr := httptest.NewRecorder()
+
+	s.GetOrderByTrack(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+	if db.LastGetTrack != "TRACK3" {
+		t.Fatalf("expected db lookup by TRACK3, got %q", db.LastGetTrack)
+	}
+
+	var got model.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if got.OrderUID != "order-3" {
+		t.Fatalf("expected OrderUID order-3, got %q", got.OrderUID)
+	}
+}
+
+func TestGetOrderByTrack_NotFound(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+
+	db.GetByTrackFunc = func(track string) (model.Order, error) {
+		return model.Order{}, pgx.ErrNoRows
+	}
+
+	s := NewServer(db, c)
+
+	req := httptest.NewRequest(http.MethodGet, "/order/by-track/missing", nil)
+	req = mux.SetURLVars(req, map[string]string{"track": "missing"})
+	rr := httptest.NewRecorder()
+
+	s.GetOrderByTrack(rr, req)
+
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected status 404, got %d", rr.Code)
+	}
+}
+
+func TestFindOrders_NoFilter_BadRequest(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+
+	s := NewServer(db, c)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+	rr := httptest.NewRecorder()
+
+	s.FindOrders(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rr.Code)
+	}
+	if db.FindCalls != 0 {
+		t.Fatalf("expected db.FindOrders not called, got %d", db.FindCalls)
+	}
+}
+
+func TestFindOrders_ByTransaction(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+
+	db.FindOrdersFunc = func(f database.OrderFilter) ([]model.Order, error) {
+		return []model.Order{{OrderUID: "order-4"}}, nil
+	}
+
+	s := NewServer(db, c)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders?transaction=txn-4", nil)
+	rr := httptest.NewRecorder()
+
+	s.FindOrders(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+	if db.LastFindFilter.Transaction != "txn-4" {
+		t.Fatalf("expected filter transaction=txn-4, got %+v", db.LastFindFilter)
+	}
+
+	var got []model.Order
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if len(got) != 1 || got[0].OrderUID != "order-4" {
+		t.Fatalf("unexpected result: %+v", got)
+	}
+}
+
+func TestSearchOrders_Pagination(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+
+	var gotLimit, gotOffset int
+	db.SearchFunc = func(query string, limit, offset int) ([]database.SearchResult, int, error) {
+		gotLimit, gotOffset = limit, offset
+		return []database.SearchResult{{Order: model.Order{OrderUID: "order-5"}, Rank: 0.5}}, 11, nil
+	}
+
+	s := NewServer(db, c)
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/search?q=Nike+sneakers&limit=10&offset=10", nil)
+	rr := httptest.NewRecorder()
+
+	s.SearchOrders(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+	if db.LastSearch != "Nike sneakers" {
+		t.Fatalf("expected query %q, got %q", "Nike sneakers", db.LastSearch)
+	}
+	if gotLimit != 10 || gotOffset != 10 {
+		t.Fatalf("expected limit=10 offset=10, got limit=%d offset=%d", gotLimit, gotOffset)
+	}
+
+	var got searchResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if got.Total != 11 || len(got.Results) != 1 || got.Results[0].Order.OrderUID != "order-5" {
+		t.Fatalf("unexpected response: %+v", got)
+	}
+}
+
+func TestSearchOrders_BadParams(t *testing.T) {
+	for _, target := range []string{
+		"/orders/search",
+		"/orders/search?q=nike&limit=0",
+		"/orders/search?q=nike&limit=1000",
+		"/orders/search?q=nike&offset=-1",
+	} {
+		db := &database.MockDB{}
+		s := NewServer(db, &cache.MockCache{})
+
+		rr := httptest.NewRecorder()
+		s.SearchOrders(rr, httptest.NewRequest(http.MethodGet, target, nil))
+
+		if rr.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected status 400, got %d", target, rr.Code)
+		}
+		if db.SearchCalls != 0 {
+			t.Fatalf("%s: expected db.SearchOrders not called", target)
+		}
+	}
+}
+
+func TestGetCustomerOrders_PageAndTotals(t *testing.T) {
+	db := &database.MockDB{}
+	c := &cache.MockCache{}
+
+	var gotLimit, gotOffset int
+	db.GetCustomerFunc = func(customerID string, limit, offset int) (database.CustomerOrders, error) {
+		gotLimit, gotOffset = limit, offset
+		return database.CustomerOrders{
+			Orders: []model.Order{
+				{OrderUID: "o-3", CustomerID: customerID, Payment: model.Payment{Currency: "USD", Amount: 100}},
+			},
+			Count:  3,
+			Totals: map[string]int64{"USD": 350, "RUB": 5000},
+		}, nil
+	}
+
+	s := NewServer(db, c)
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/cust-1/orders?limit=1&offset=2", nil)
+	req = mux.SetURLVars(req, map[string]string{"customer_id": "cust-1"})
+	rr := httptest.NewRecorder()
+
+	s.GetCustomerOrders(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d, body=%s", rr.Code, rr.Body.String())
+	}
+	if db.LastCustomerID != "cust-1" || gotLimit != 1 || gotOffset != 2 {
+		t.Fatalf("expected lookup for cust-1 with limit 1 offset 2, got %q %d %d", db.LastCustomerID, gotLimit, gotOffset)
+	}
+
+	var got customerOrdersResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatalf("bad json: %v", err)
+	}
+	if got.OrderCount != 3 || len(got.Orders) != 1 || got.Limit != 1 || got.Offset != 2 {
+		t.Fatalf("expected 1 of 3 orders, got %+v", got)
+	}
+	if got.Totals["USD"] != 350 || got.Totals["RUB"] != 5000 {
+		t.Fatalf("unexpected totals: %v", got.Totals)
+	}
+}
+
+func TestGetCustomerOrders_BadLimit(t *testing.T) {
+	db := &database.MockDB{}
+	s := NewServer(db, &cache.MockCache{})
+
+	req := httptest.NewRequest(http.MethodGet, "/customers/cust-1/orders?limit=1000", nil)
+	req = mux.SetURLVars(req, map[string]string{"customer_id": "cust-1"})
+	rr := httptest.NewRecorder()
+
+	s.GetCustomerOrders(rr, req)
+
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", rr.Code)
+	}
+	if db.GetCustomerCalls != 0 {
+		t.Fatalf("expected no db call, got %d", db.GetCustomerCalls)
+	}
+}
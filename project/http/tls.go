@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// TLSConfig enables HTTPS when CertFile and KeyFile are set. The pair is
+// re-read whenever either file changes, so certificates can be renewed
+// without a restart. ClientCAFile makes admin routes require a client
+// certificate signed by one of its CAs.
+type TLSConfig struct {
+	CertFile       string
+	KeyFile        string
+	ClientCAFile   string
+	ReloadInterval time.Duration
+}
+
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" && c.KeyFile != ""
+}
+
+// serverConfig loads the certificate and starts watching it until ctx is
+// done.
+func (c TLSConfig) serverConfig(ctx context.Context) (*tls.Config, error) {
+	reloader, err := newCertReloader(c.CertFile, c.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+	interval := c.ReloadInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	go reloader.watch(ctx, interval)
+
+	cfg := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: reloader.getCertificate,
+	}
+	if c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", c.ClientCAFile)
+		}
+		cfg.ClientCAs = pool
+		// Only admin routes insist on a certificate, see requireClientCert.
+		cfg.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return cfg, nil
+}
+
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	modTime, err := r.lastModified()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	r.cert = &cert
+	r.modTime = modTime
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *certReloader) lastModified() (time.Time, error) {
+	var latest time.Time
+	for _, path := range []string{r.certFile, r.keyFile} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if fi.ModTime().After(latest) {
+			latest = fi.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// reload keeps serving the previous pair when the new one can't be loaded,
+// e.g. when the certificate has been replaced but the key not yet.
+func (r *certReloader) reload() {
+	modTime, err := r.lastModified()
+	if err != nil {
+		log.Printf("Can't stat TLS certificate: %v", err)
+		return
+	}
+	r.mu.RLock()
+	unchanged := modTime.Equal(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	if err := r.load(modTime); err != nil {
+		log.Printf("Can't reload TLS certificate: %v", err)
+		return
+	}
+	log.Printf("Reloaded TLS certificate %s", r.certFile)
+}
+
+func (r *certReloader) watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reload()
+		}
+	}
+}
+
+// requireClientCert rejects requests without a verified client certificate
+// when mTLS is configured.
+func (s *Server) requireClientCert(h http.HandlerFunc) http.HandlerFunc {
+	if s.TLS.ClientCAFile == "" {
+		return h
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+			s.problem(w, r, http.StatusForbidden, "Client certificate is required")
+			return
+		}
+		h(w, r)
+	}
+}
@@ -0,0 +1,268 @@
+package kafkaconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const (
+	MechanismPlain       = "PLAIN"
+	MechanismSCRAMSHA256 = "SCRAM-SHA-256"
+	MechanismSCRAMSHA512 = "SCRAM-SHA-512"
+)
+
+const dialTimeout = 10 * time.Second
+
+// Config describes how to reach the cluster and is shared by readers and
+// writers. Zero tunables keep the kafka-go defaults.
+type Config struct {
+	Brokers []string
+	SASL    SASL
+	TLS     TLS
+
+	MinBytes       int
+	MaxBytes       int
+	MaxWait        time.Duration
+	StartOffset    int64
+	CommitInterval time.Duration
+}
+
+type SASL struct {
+	Mechanism string
+	Username  string
+	Password  string
+}
+
+// TLS is enabled by Enabled or by any of the files. CertFile and KeyFile
+// configure a client certificate.
+type TLS struct {
+	Enabled            bool
+	CAFile             string
+	CertFile           string
+	KeyFile            string
+	InsecureSkipVerify bool
+}
+
+// FromEnv reads KAFKA_* variables. KAFKA_BROKERS is a comma-separated list;
+// KAFKA_BROKER is still accepted for a single broker.
+func FromEnv(defaultBroker string) (Config, error) {
+	cfg := Config{
+		Brokers: splitList(os.Getenv("KAFKA_BROKERS")),
+		SASL: SASL{
+			Mechanism: strings.ToUpper(os.Getenv("KAFKA_SASL_MECHANISM")),
+			Username:  os.Getenv("KAFKA_SASL_USERNAME"),
+			Password:  os.Getenv("KAFKA_SASL_PASSWORD"),
+		},
+		TLS: TLS{
+			Enabled:            os.Getenv("KAFKA_TLS") == "true",
+			CAFile:             os.Getenv("KAFKA_TLS_CA_FILE"),
+			CertFile:           os.Getenv("KAFKA_TLS_CERT_FILE"),
+			KeyFile:            os.Getenv("KAFKA_TLS_KEY_FILE"),
+			InsecureSkipVerify: os.Getenv("KAFKA_TLS_INSECURE_SKIP_VERIFY") == "true",
+		},
+	}
+	if len(cfg.Brokers) == 0 {
+		cfg.Brokers = splitList(os.Getenv("KAFKA_BROKER"))
+	}
+	if len(cfg.Brokers) == 0 {
+		cfg.Brokers = []string{defaultBroker}
+	}
+
+	var err error
+	if cfg.MinBytes, err = envInt("KAFKA_MIN_BYTES"); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxBytes, err = envInt("KAFKA_MAX_BYTES"); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxWait, err = envDuration("KAFKA_MAX_WAIT"); err != nil {
+		return cfg, err
+	}
+	if cfg.CommitInterval, err = envDuration("KAFKA_COMMIT_INTERVAL"); err != nil {
+		return cfg, err
+	}
+	switch v := os.Getenv("KAFKA_START_OFFSET"); v {
+	case "", "first":
+		cfg.StartOffset = kafka.FirstOffset
+	case "last":
+		cfg.StartOffset = kafka.LastOffset
+	default:
+		return cfg, fmt.Errorf("KAFKA_START_OFFSET must be first or last, got %q", v)
+	}
+
+	if _, err := cfg.mechanism(); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+func (c Config) mechanism() (sasl.Mechanism, error) {
+	switch c.SASL.Mechanism {
+	case "":
+		return nil, nil
+	case MechanismPlain:
+		return plain.Mechanism{Username: c.SASL.Username, Password: c.SASL.Password}, nil
+	case MechanismSCRAMSHA256:
+		return scram.Mechanism(scram.SHA256, c.SASL.Username, c.SASL.Password)
+	case MechanismSCRAMSHA512:
+		return scram.Mechanism(scram.SHA512, c.SASL.Username, c.SASL.Password)
+	}
+	return nil, fmt.Errorf("unsupported SASL mechanism %q", c.SASL.Mechanism)
+}
+
+func (c Config) tlsConfig() (*tls.Config, error) {
+	t := c.TLS
+	if !t.Enabled && t.CAFile == "" && t.CertFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: t.InsecureSkipVerify}
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", t.CAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if t.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// ReaderConfig returns a consumer group reader configuration for one or
+// more topics.
+func (c Config) ReaderConfig(group string, topics ...string) (kafka.ReaderConfig, error) {
+	mechanism, err := c.mechanism()
+	if err != nil {
+		return kafka.ReaderConfig{}, err
+	}
+	tlsCfg, err := c.tlsConfig()
+	if err != nil {
+		return kafka.ReaderConfig{}, err
+	}
+
+	rc := kafka.ReaderConfig{
+		Brokers: c.Brokers,
+		GroupID: group,
+		Dialer: &kafka.Dialer{
+			Timeout:       dialTimeout,
+			DualStack:     true,
+			TLS:           tlsCfg,
+			SASLMechanism: mechanism,
+		},
+		MinBytes:       c.MinBytes,
+		MaxBytes:       c.MaxBytes,
+		MaxWait:        c.MaxWait,
+		StartOffset:    c.StartOffset,
+		CommitInterval: c.CommitInterval,
+	}
+	if len(topics) == 1 {
+		rc.Topic = topics[0]
+	} else {
+		rc.GroupTopics = topics
+	}
+	return rc, nil
+}
+
+// ListTopics returns the names of the cluster's topics, without internal
+// ones such as __consumer_offsets.
+func (c Config) ListTopics(ctx context.Context) ([]string, error) {
+	transport, err := c.transport()
+	if err != nil {
+		return nil, err
+	}
+	client := &kafka.Client{Addr: kafka.TCP(c.Brokers...), Transport: transport}
+
+	resp, err := client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, err
+	}
+	var topics []string
+	for _, t := range resp.Topics {
+		if t.Error == nil && !t.Internal && !strings.HasPrefix(t.Name, "__") {
+			topics = append(topics, t.Name)
+		}
+	}
+	return topics, nil
+}
+
+// Writer returns a writer for topic; an empty topic lets each message name
+// its own.
+func (c Config) Writer(topic string, balancer kafka.Balancer) (*kafka.Writer, error) {
+	transport, err := c.transport()
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Writer{
+		Addr:      kafka.TCP(c.Brokers...),
+		Topic:     topic,
+		Balancer:  balancer,
+		Transport: transport,
+	}, nil
+}
+
+func (c Config) transport() (*kafka.Transport, error) {
+	mechanism, err := c.mechanism()
+	if err != nil {
+		return nil, err
+	}
+	tlsCfg, err := c.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &kafka.Transport{DialTimeout: dialTimeout, TLS: tlsCfg, SASL: mechanism}, nil
+}
+
+func envInt(key string) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return n, nil
+}
+
+func envDuration(key string) (time.Duration, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return d, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
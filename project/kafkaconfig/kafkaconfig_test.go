@@ -0,0 +1,88 @@
+package kafkaconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+func TestFromEnv_Defaults(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "")
+	t.Setenv("KAFKA_BROKER", "")
+
+	cfg, err := FromEnv("kafka:9092")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cfg.Brokers) != 1 || cfg.Brokers[0] != "kafka:9092" {
+		t.Fatalf("expected default broker, got %v", cfg.Brokers)
+	}
+	if cfg.StartOffset != kafka.FirstOffset {
+		t.Fatalf("expected first offset, got %d", cfg.StartOffset)
+	}
+
+	rc, err := cfg.ReaderConfig("group", "orders")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rc.Dialer.TLS != nil || rc.Dialer.SASLMechanism != nil {
+		t.Fatalf("expected plaintext dialer, got %+v", rc.Dialer)
+	}
+}
+
+func TestFromEnv_BrokersSASLAndTunables(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "k1:9093, k2:9093")
+	t.Setenv("KAFKA_SASL_MECHANISM", "scram-sha-512")
+	t.Setenv("KAFKA_SASL_USERNAME", "svc")
+	t.Setenv("KAFKA_SASL_PASSWORD", "secret")
+	t.Setenv("KAFKA_TLS", "true")
+	t.Setenv("KAFKA_MIN_BYTES", "1024")
+	t.Setenv("KAFKA_MAX_WAIT", "500ms")
+	t.Setenv("KAFKA_START_OFFSET", "last")
+	t.Setenv("KAFKA_COMMIT_INTERVAL", "1s")
+
+	cfg, err := FromEnv("kafka:9092")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rc, err := cfg.ReaderConfig("group", "orders")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rc.Brokers) != 2 || rc.Brokers[1] != "k2:9093" {
+		t.Fatalf("expected two brokers, got %v", rc.Brokers)
+	}
+	if rc.Dialer.SASLMechanism == nil || rc.Dialer.SASLMechanism.Name() != MechanismSCRAMSHA512 {
+		t.Fatalf("expected SCRAM-SHA-512, got %v", rc.Dialer.SASLMechanism)
+	}
+	if rc.Dialer.TLS == nil {
+		t.Fatalf("expected TLS dialer")
+	}
+	if rc.MinBytes != 1024 || rc.MaxWait != 500*time.Millisecond || rc.StartOffset != kafka.LastOffset || rc.CommitInterval != time.Second {
+		t.Fatalf("expected tunables to be applied, got %+v", rc)
+	}
+
+	w, err := cfg.Writer("orders_dlq", &kafka.LeastBytes{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tr := w.Transport.(*kafka.Transport)
+	if tr.SASL == nil || tr.TLS == nil || w.Addr.String() != "k1:9093,k2:9093" {
+		t.Fatalf("expected writer to share brokers and security, got %+v", w)
+	}
+}
+
+func TestFromEnv_Invalid(t *testing.T) {
+	for key, value := range map[string]string{
+		"KAFKA_SASL_MECHANISM": "GSSAPI",
+		"KAFKA_START_OFFSET":   "middle",
+		"KAFKA_MAX_WAIT":       "soon",
+	} {
+		t.Setenv(key, value)
+		if _, err := FromEnv("kafka:9092"); err == nil {
+			t.Fatalf("expected error for %s=%s", key, value)
+		}
+		t.Setenv(key, "")
+	}
+}
@@ -0,0 +1,168 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const keySize = 32
+
+var ErrUnknownKey = errors.New("unknown key id")
+
+// Keyring holds the key-encryption keys by ID and the key used for blind
+// indexes. New data is always sealed with the active key; the others are kept
+// to open rows that have not been rotated yet.
+type Keyring struct {
+	active   string
+	keys     map[string][]byte
+	indexKey []byte
+}
+
+type file struct {
+	Active   string            `json:"active"`
+	Keys     map[string]string `json:"keys"`
+	IndexKey string            `json:"index_key"`
+}
+
+// Load reads a keyring file with base64-encoded 256-bit keys:
+//
+//	{"active": "k2", "keys": {"k1": "...", "k2": "..."}, "index_key": "..."}
+//
+// The index key must never change, otherwise lookups by encrypted fields stop
+// matching existing rows.
+func Load(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var f file
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+
+	k := &Keyring{active: f.Active, keys: make(map[string][]byte, len(f.Keys))}
+	for id, enc := range f.Keys {
+		key, err := decodeKey(enc)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		k.keys[id] = key
+	}
+	if _, ok := k.keys[k.active]; !ok {
+		return nil, fmt.Errorf("active key %q is not in the keyring", k.active)
+	}
+	if k.indexKey, err = decodeKey(f.IndexKey); err != nil {
+		return nil, fmt.Errorf("index_key: %w", err)
+	}
+	return k, nil
+}
+
+// New builds a keyring in memory, mostly for tests.
+func New(active string, keys map[string][]byte, indexKey []byte) *Keyring {
+	return &Keyring{active: active, keys: keys, indexKey: indexKey}
+}
+
+func decodeKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(key) != keySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", keySize, len(key))
+	}
+	return key, nil
+}
+
+func (k *Keyring) ActiveKeyID() string { return k.active }
+
+// Sealed is an envelope-encrypted value: Ciphertext is encrypted with a
+// random data key, which is stored wrapped by the key-encryption key KeyID.
+type Sealed struct {
+	KeyID      string
+	DEK        []byte
+	Ciphertext []byte
+}
+
+// Seal encrypts plaintext with a fresh data key. aad binds the ciphertext to
+// its context, e.g. the row ID, and must be passed to Open unchanged.
+func (k *Keyring) Seal(plaintext, aad []byte) (Sealed, error) {
+	dek := make([]byte, keySize)
+	if _, err := rand.Read(dek); err != nil {
+		return Sealed{}, err
+	}
+
+	ciphertext, err := encrypt(dek, plaintext, aad)
+	if err != nil {
+		return Sealed{}, err
+	}
+	wrapped, err := encrypt(k.keys[k.active], dek, []byte(k.active))
+	if err != nil {
+		return Sealed{}, err
+	}
+	return Sealed{KeyID: k.active, DEK: wrapped, Ciphertext: ciphertext}, nil
+}
+
+func (k *Keyring) Open(s Sealed, aad []byte) ([]byte, error) {
+	kek, ok := k.keys[s.KeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownKey, s.KeyID)
+	}
+	dek, err := decrypt(kek, s.DEK, []byte(s.KeyID))
+	if err != nil {
+		return nil, fmt.Errorf("unwrap data key: %w", err)
+	}
+	return decrypt(dek, s.Ciphertext, aad)
+}
+
+// BlindIndex returns a keyed hash of value for equality lookups on encrypted
+// fields. field separates the hash spaces of different fields, and values are
+// compared case-insensitively.
+func (k *Keyring) BlindIndex(field, value string) string {
+	mac := hmac.New(sha256.New, k.indexKey)
+	mac.Write([]byte(field))
+	mac.Write([]byte{0})
+	mac.Write([]byte(strings.ToLower(strings.TrimSpace(value))))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func encrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize(), gcm.NonceSize()+len(plaintext)+gcm.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, aad), nil
+}
+
+func decrypt(key, data, aad []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, aad)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,92 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testKey(b byte) []byte { return bytes.Repeat([]byte{b}, keySize) }
+
+func TestSealOpen_RoundTripAcrossRotation(t *testing.T) {
+	old := New("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+
+	sealed, err := old.Seal([]byte("secret"), []byte("order-1"))
+	if err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+	if sealed.KeyID != "k1" || bytes.Contains(sealed.Ciphertext, []byte("secret")) {
+		t.Fatalf("unexpected sealed value %+v", sealed)
+	}
+
+	rotated := New("k2", map[string][]byte{"k1": testKey(1), "k2": testKey(2)}, testKey(9))
+	plain, err := rotated.Open(sealed, []byte("order-1"))
+	if err != nil || string(plain) != "secret" {
+		t.Fatalf("expected retired key to still open data, got %q %v", plain, err)
+	}
+
+	resealed, _ := rotated.Seal(plain, []byte("order-1"))
+	if resealed.KeyID != "k2" {
+		t.Fatalf("expected new data to use active key, got %q", resealed.KeyID)
+	}
+}
+
+func TestOpen_Failures(t *testing.T) {
+	k := New("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+	sealed, _ := k.Seal([]byte("secret"), []byte("order-1"))
+
+	if _, err := k.Open(sealed, []byte("order-2")); err == nil {
+		t.Fatalf("expected ciphertext bound to another row to fail")
+	}
+
+	sealed.KeyID = "k0"
+	if _, err := k.Open(sealed, []byte("order-1")); !errors.Is(err, ErrUnknownKey) {
+		t.Fatalf("expected unknown key error, got %v", err)
+	}
+}
+
+func TestBlindIndex(t *testing.T) {
+	k := New("k1", map[string][]byte{"k1": testKey(1)}, testKey(9))
+
+	if k.BlindIndex("email", "Test@Gmail.com ") != k.BlindIndex("email", "test@gmail.com") {
+		t.Fatalf("expected blind index to ignore case and spaces")
+	}
+	if k.BlindIndex("email", "x") == k.BlindIndex("phone", "x") {
+		t.Fatalf("expected different fields to hash differently")
+	}
+
+	other := New("k2", map[string][]byte{"k2": testKey(2)}, testKey(9))
+	if k.BlindIndex("email", "x") != other.BlindIndex("email", "x") {
+		t.Fatalf("expected blind index to survive key rotation")
+	}
+}
+
+func TestLoad(t *testing.T) {
+	enc := func(b byte) string { return base64.StdEncoding.EncodeToString(testKey(b)) }
+	path := filepath.Join(t.TempDir(), "keyring.json")
+
+	write := func(s string) {
+		if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	write(`{"active":"k1","keys":{"k1":"` + enc(1) + `"},"index_key":"` + enc(9) + `"}`)
+	k, err := Load(path)
+	if err != nil || k.ActiveKeyID() != "k1" {
+		t.Fatalf("expected keyring to load, got %v", err)
+	}
+
+	write(`{"active":"k2","keys":{"k1":"` + enc(1) + `"},"index_key":"` + enc(9) + `"}`)
+	if _, err := Load(path); err == nil {
+		t.Fatalf("expected error for missing active key")
+	}
+
+	write(`{"active":"k1","keys":{"k1":"c2hvcnQ="},"index_key":"` + enc(9) + `"}`)
+	if _, err := Load(path); err == nil {
+		t.Fatalf("expected error for short key")
+	}
+}
@@ -0,0 +1,16 @@
+package model
+
+import "time"
+
+const EventOrderStored = "OrderStored"
+
+// OrderStored is published once an order is committed. It carries
+// identifiers only, so downstream services fetch personal data through the
+// API.
+type OrderStored struct {
+	OrderUID    string    `json:"order_uid"`
+	TrackNumber string    `json:"track_number"`
+	CustomerID  string    `json:"customer_id"`
+	SourceTopic string    `json:"source_topic,omitempty"`
+	StoredAt    time.Time `json:"stored_at"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "time"
+
 type Order struct {
 	OrderUID          string   `json:"order_uid" validate:"required"`
 	TrackNumber       string   `json:"track_number" validate:"required"`
@@ -15,16 +17,24 @@ type Order struct {
 	SmID              int      `json:"sm_id"`
 	DateCreated       string   `json:"date_created" validate:"required"`
 	OofShard          string   `json:"oof_shard"`
+	SchemaVersion     int      `json:"schema_version,omitempty"`
+
+	// CreatedAt is when the row was stored. It is filled on reads and never
+	// serialized.
+	CreatedAt time.Time `json:"-"`
+	// SourceTopic is the Kafka topic the order was consumed from, stored in
+	// its own column. Empty for orders that didn't come through Kafka.
+	SourceTopic string `json:"-"`
 }
 
 type Delivery struct {
-	Name    string `json:"name" validate:"required"`
-	Phone   string `json:"phone" validate:"required"`
-	Zip     string `json:"zip" validate:"required"`
+	Name    string `json:"name" validate:"required" pii:"name"`
+	Phone   string `json:"phone" validate:"required" pii:"phone"`
+	Zip     string `json:"zip" validate:"required" pii:"full"`
 	City    string `json:"city" validate:"required"`
-	Address string `json:"address" validate:"required"`
+	Address string `json:"address" validate:"required" pii:"full"`
 	Region  string `json:"region" validate:"required"`
-	Email   string `json:"email" validate:"required,email"`
+	Email   string `json:"email" validate:"required,email" pii:"email"`
 }
 
 type Payment struct {
@@ -0,0 +1,76 @@
+package ordercsv
+
+import (
+	"awesomeProject3/project/model"
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// Header lists the columns written by Writer. Every row is one item, with the
+// order, delivery and payment fields repeated for each item of the order.
+var Header = []string{
+	"order_uid", "track_number", "entry", "customer_id", "date_created",
+	"locale", "delivery_service", "shardkey", "sm_id", "oof_shard",
+	"delivery_name", "delivery_phone", "delivery_zip", "delivery_city",
+	"delivery_address", "delivery_region", "delivery_email",
+	"payment_transaction", "payment_currency", "payment_provider", "payment_amount",
+	"payment_dt", "payment_bank", "payment_delivery_cost", "payment_goods_total", "payment_custom_fee",
+	"item_chrt_id", "item_track_number", "item_price", "item_rid", "item_name", "item_sale",
+	"item_size", "item_total_price", "item_nm_id", "item_brand", "item_status",
+}
+
+type Writer struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: csv.NewWriter(w)}
+}
+
+func (w *Writer) Write(o model.Order) error {
+	if !w.wroteHeader {
+		if err := w.w.Write(Header); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+
+	base := []string{
+		o.OrderUID, o.TrackNumber, o.Entry, o.CustomerID, o.DateCreated,
+		o.Locale, o.DeliveryService, o.Shardkey, strconv.Itoa(o.SmID), o.OofShard,
+		o.Delivery.Name, o.Delivery.Phone, o.Delivery.Zip, o.Delivery.City,
+		o.Delivery.Address, o.Delivery.Region, o.Delivery.Email,
+		o.Payment.Transaction, o.Payment.Currency, o.Payment.Provider, strconv.Itoa(o.Payment.Amount),
+		strconv.FormatInt(o.Payment.PaymentDT, 10), o.Payment.Bank, strconv.Itoa(o.Payment.DeliveryCost),
+		strconv.Itoa(o.Payment.GoodsTotal), strconv.Itoa(o.Payment.CustomFee),
+	}
+
+	items := o.Items
+	if len(items) == 0 {
+		items = []model.Items{{}}
+	}
+	for _, it := range items {
+		row := append(base[:len(base):len(base)],
+			strconv.Itoa(it.ChrtID), it.TrackNumber, strconv.Itoa(it.Price), it.Rid, it.Name,
+			strconv.Itoa(it.Sale), it.Size, strconv.Itoa(it.TotalPrice), strconv.Itoa(it.NmID),
+			it.Brand, strconv.Itoa(it.Status),
+		)
+		if err := w.w.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *Writer) Flush() error {
+	if !w.wroteHeader {
+		if err := w.w.Write(Header); err != nil {
+			return err
+		}
+		w.wroteHeader = true
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
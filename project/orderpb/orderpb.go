@@ -0,0 +1,307 @@
+package orderpb
+
+import (
+	"awesomeProject3/project/model"
+	"fmt"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func Marshal(o model.Order) []byte {
+	return appendOrder(nil, o)
+}
+
+func MarshalList(orders []model.Order) []byte {
+	var b []byte
+	for _, o := range orders {
+		b = appendMessage(b, 1, appendOrder(nil, o))
+	}
+	return b
+}
+
+func Unmarshal(b []byte) (model.Order, error) {
+	var o model.Order
+	if err := consumeOrder(b, &o); err != nil {
+		return model.Order{}, err
+	}
+	return o, nil
+}
+
+func UnmarshalList(b []byte) ([]model.Order, error) {
+	var orders []model.Order
+	err := consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num != 1 {
+			return 0, nil
+		}
+		var o model.Order
+		n, err := message(typ, b, func(m []byte) error { return consumeOrder(m, &o) })
+		orders = append(orders, o)
+		return n, err
+	})
+	return orders, err
+}
+
+func appendOrder(b []byte, o model.Order) []byte {
+	b = appendString(b, 1, o.OrderUID)
+	b = appendString(b, 2, o.TrackNumber)
+	b = appendString(b, 3, o.Entry)
+	b = appendMessage(b, 4, appendDelivery(nil, o.Delivery))
+	b = appendMessage(b, 5, appendPayment(nil, o.Payment))
+	for _, it := range o.Items {
+		b = appendMessage(b, 6, appendItem(nil, it))
+	}
+	b = appendString(b, 7, o.Locale)
+	b = appendString(b, 8, o.InternalSignature)
+	b = appendString(b, 9, o.CustomerID)
+	b = appendString(b, 10, o.DeliveryService)
+	b = appendString(b, 11, o.Shardkey)
+	b = appendInt(b, 12, int64(o.SmID))
+	b = appendString(b, 13, o.DateCreated)
+	b = appendString(b, 14, o.OofShard)
+	return b
+}
+
+func appendDelivery(b []byte, d model.Delivery) []byte {
+	b = appendString(b, 1, d.Name)
+	b = appendString(b, 2, d.Phone)
+	b = appendString(b, 3, d.Zip)
+	b = appendString(b, 4, d.City)
+	b = appendString(b, 5, d.Address)
+	b = appendString(b, 6, d.Region)
+	b = appendString(b, 7, d.Email)
+	return b
+}
+
+func appendPayment(b []byte, p model.Payment) []byte {
+	b = appendString(b, 1, p.Transaction)
+	b = appendString(b, 2, p.RequestID)
+	b = appendString(b, 3, p.Currency)
+	b = appendString(b, 4, p.Provider)
+	b = appendInt(b, 5, int64(p.Amount))
+	b = appendInt(b, 6, p.PaymentDT)
+	b = appendString(b, 7, p.Bank)
+	b = appendInt(b, 8, int64(p.DeliveryCost))
+	b = appendInt(b, 9, int64(p.GoodsTotal))
+	b = appendInt(b, 10, int64(p.CustomFee))
+	return b
+}
+
+func appendItem(b []byte, it model.Items) []byte {
+	b = appendInt(b, 1, int64(it.ChrtID))
+	b = appendString(b, 2, it.TrackNumber)
+	b = appendInt(b, 3, int64(it.Price))
+	b = appendString(b, 4, it.Rid)
+	b = appendString(b, 5, it.Name)
+	b = appendInt(b, 6, int64(it.Sale))
+	b = appendString(b, 7, it.Size)
+	b = appendInt(b, 8, int64(it.TotalPrice))
+	b = appendInt(b, 9, int64(it.NmID))
+	b = appendString(b, 10, it.Brand)
+	b = appendInt(b, 11, int64(it.Status))
+	return b
+}
+
+func appendString(b []byte, num protowire.Number, v string) []byte {
+	if v == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, v)
+}
+
+func appendInt(b []byte, num protowire.Number, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(v))
+}
+
+func appendMessage(b []byte, num protowire.Number, m []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, m)
+}
+
+func consumeOrder(b []byte, o *model.Order) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return str(typ, b, &o.OrderUID)
+		case 2:
+			return str(typ, b, &o.TrackNumber)
+		case 3:
+			return str(typ, b, &o.Entry)
+		case 4:
+			return message(typ, b, func(m []byte) error { return consumeDelivery(m, &o.Delivery) })
+		case 5:
+			return message(typ, b, func(m []byte) error { return consumePayment(m, &o.Payment) })
+		case 6:
+			var it model.Items
+			n, err := message(typ, b, func(m []byte) error { return consumeItem(m, &it) })
+			o.Items = append(o.Items, it)
+			return n, err
+		case 7:
+			return str(typ, b, &o.Locale)
+		case 8:
+			return str(typ, b, &o.InternalSignature)
+		case 9:
+			return str(typ, b, &o.CustomerID)
+		case 10:
+			return str(typ, b, &o.DeliveryService)
+		case 11:
+			return str(typ, b, &o.Shardkey)
+		case 12:
+			return integer(typ, b, &o.SmID)
+		case 13:
+			return str(typ, b, &o.DateCreated)
+		case 14:
+			return str(typ, b, &o.OofShard)
+		}
+		return 0, nil
+	})
+}
+
+func consumeDelivery(b []byte, d *model.Delivery) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return str(typ, b, &d.Name)
+		case 2:
+			return str(typ, b, &d.Phone)
+		case 3:
+			return str(typ, b, &d.Zip)
+		case 4:
+			return str(typ, b, &d.City)
+		case 5:
+			return str(typ, b, &d.Address)
+		case 6:
+			return str(typ, b, &d.Region)
+		case 7:
+			return str(typ, b, &d.Email)
+		}
+		return 0, nil
+	})
+}
+
+func consumePayment(b []byte, p *model.Payment) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return str(typ, b, &p.Transaction)
+		case 2:
+			return str(typ, b, &p.RequestID)
+		case 3:
+			return str(typ, b, &p.Currency)
+		case 4:
+			return str(typ, b, &p.Provider)
+		case 5:
+			return integer(typ, b, &p.Amount)
+		case 6:
+			return integer(typ, b, &p.PaymentDT)
+		case 7:
+			return str(typ, b, &p.Bank)
+		case 8:
+			return integer(typ, b, &p.DeliveryCost)
+		case 9:
+			return integer(typ, b, &p.GoodsTotal)
+		case 10:
+			return integer(typ, b, &p.CustomFee)
+		}
+		return 0, nil
+	})
+}
+
+func consumeItem(b []byte, it *model.Items) error {
+	return consumeFields(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch num {
+		case 1:
+			return integer(typ, b, &it.ChrtID)
+		case 2:
+			return str(typ, b, &it.TrackNumber)
+		case 3:
+			return integer(typ, b, &it.Price)
+		case 4:
+			return str(typ, b, &it.Rid)
+		case 5:
+			return str(typ, b, &it.Name)
+		case 6:
+			return integer(typ, b, &it.Sale)
+		case 7:
+			return str(typ, b, &it.Size)
+		case 8:
+			return integer(typ, b, &it.TotalPrice)
+		case 9:
+			return integer(typ, b, &it.NmID)
+		case 10:
+			return str(typ, b, &it.Brand)
+		case 11:
+			return integer(typ, b, &it.Status)
+		}
+		return 0, nil
+	})
+}
+
+// consumeFields walks the fields of a message. fn returns the number of bytes
+// it consumed, or 0 to have an unknown field skipped.
+func consumeFields(b []byte, fn func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		m, err := fn(num, typ, b)
+		if err != nil {
+			return fmt.Errorf("field %d: %w", num, err)
+		}
+		if m == 0 {
+			m = protowire.ConsumeFieldValue(num, typ, b)
+			if m < 0 {
+				return protowire.ParseError(m)
+			}
+		}
+		b = b[m:]
+	}
+	return nil
+}
+
+func str(typ protowire.Type, b []byte, dst *string) (int, error) {
+	if typ != protowire.BytesType {
+		return 0, fmt.Errorf("unexpected wire type %d", typ)
+	}
+	v, n := protowire.ConsumeString(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	*dst = v
+	return n, nil
+}
+
+func integer[T ~int | ~int64](typ protowire.Type, b []byte, dst *T) (int, error) {
+	if typ != protowire.VarintType {
+		return 0, fmt.Errorf("unexpected wire type %d", typ)
+	}
+	v, n := protowire.ConsumeVarint(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	// order.proto uses int64; model fields that are Go int must not wrap
+	// where int is 32 bits.
+	if int64(T(int64(v))) != int64(v) {
+		return 0, fmt.Errorf("value %d out of range", int64(v))
+	}
+	*dst = T(int64(v))
+	return n, nil
+}
+
+func message(typ protowire.Type, b []byte, fn func([]byte) error) (int, error) {
+	if typ != protowire.BytesType {
+		return 0, fmt.Errorf("unexpected wire type %d", typ)
+	}
+	m, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return 0, protowire.ParseError(n)
+	}
+	return n, fn(m)
+}
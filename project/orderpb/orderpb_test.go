@@ -0,0 +1,219 @@
+package orderpb
+
+import (
+	"awesomeProject3/project/model"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func sampleOrder() model.Order {
+	return model.Order{
+		OrderUID:    "b563feb7b2b84b6test",
+		TrackNumber: "WBILMTESTTRACK",
+		Entry:       "WBIL",
+		Delivery: model.Delivery{
+			Name: "Test Testov", Phone: "+9720000000", Zip: "2639809", City: "Kiryat Mozkin",
+			Address: "Ploshad Mira 15", Region: "Kraiot", Email: "test@gmail.com",
+		},
+		Payment: model.Payment{
+			Transaction: "b563feb7b2b84b6test", Currency: "USD", Provider: "wbpay", Amount: 1817,
+			PaymentDT: 1637907727, Bank: "alpha", DeliveryCost: 1500, GoodsTotal: 317,
+		},
+		Items: []model.Items{
+			{ChrtID: 9934930, TrackNumber: "WBILMTESTTRACK", Price: 453, Rid: "ab4219087a764ae0btest",
+				Name: "Mascaras", Sale: 30, Size: "0", TotalPrice: 317, NmID: 2389212, Brand: "Vivienne Sabo", Status: 202},
+			{ChrtID: 1, Name: "Sneakers", Brand: "Nike", Sale: -1},
+		},
+		Locale:          "en",
+		CustomerID:      "test",
+		DeliveryService: "meest",
+		Shardkey:        "9",
+		SmID:            99,
+		DateCreated:     "2021-11-26T06:22:19Z",
+		OofShard:        "1",
+	}
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	want := sampleOrder()
+
+	got, err := Unmarshal(Marshal(want))
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("round trip mismatch:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestMarshalList_RoundTrip(t *testing.T) {
+	a, b := sampleOrder(), sampleOrder()
+	b.OrderUID = "second"
+
+	got, err := UnmarshalList(MarshalList([]model.Order{a, b}))
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(got) != 2 || got[1].OrderUID != "second" {
+		t.Fatalf("unexpected orders: %+v", got)
+	}
+}
+
+func TestUnmarshal_Truncated(t *testing.T) {
+	data := Marshal(sampleOrder())
+
+	if _, err := Unmarshal(data[:len(data)-2]); err == nil {
+		t.Fatalf("expected error for truncated message")
+	}
+}
+
+// loadProto builds a descriptor from order.proto. It understands only the
+// subset the file uses: messages with optional and repeated fields of
+// string, int64 and message types.
+func loadProto(t *testing.T) protoreflect.FileDescriptor {
+	t.Helper()
+
+	src, err := os.ReadFile("order.proto")
+	if err != nil {
+		t.Fatalf("read order.proto: %v", err)
+	}
+	file := &descriptorpb.FileDescriptorProto{
+		Name:   proto.String("order.proto"),
+		Syntax: proto.String("proto3"),
+	}
+	scalars := map[string]descriptorpb.FieldDescriptorProto_Type{
+		"string": descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		"int64":  descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	}
+
+	var msg *descriptorpb.DescriptorProto
+	for i, line := range strings.Split(string(src), "\n") {
+		line, _, _ = strings.Cut(line, "//")
+		words := strings.Fields(strings.NewReplacer("=", " ", ";", " ").Replace(line))
+		switch {
+		case len(words) == 0, words[0] == "syntax", words[0] == "option":
+		case words[0] == "package":
+			file.Package = proto.String(words[1])
+		case words[0] == "message" && len(words) == 3 && words[2] == "{":
+			msg = &descriptorpb.DescriptorProto{Name: proto.String(words[1])}
+			file.MessageType = append(file.MessageType, msg)
+		case words[0] == "}" && msg != nil:
+			msg = nil
+		case msg != nil && (len(words) == 3 || len(words) == 4 && words[0] == "repeated"):
+			label := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+			if len(words) == 4 {
+				label = descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+				words = words[1:]
+			}
+			num, err := strconv.Atoi(words[2])
+			if err != nil {
+				t.Fatalf("order.proto:%d: bad field number %q", i+1, words[2])
+			}
+			f := &descriptorpb.FieldDescriptorProto{
+				Name:     proto.String(words[1]),
+				JsonName: proto.String(words[1]),
+				Number:   proto.Int32(int32(num)),
+				Label:    label.Enum(),
+			}
+			if typ, ok := scalars[words[0]]; ok {
+				f.Type = typ.Enum()
+			} else {
+				f.Type = descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
+				f.TypeName = proto.String("." + file.GetPackage() + "." + words[0])
+			}
+			msg.Field = append(msg.Field, f)
+		default:
+			t.Fatalf("order.proto:%d: unsupported syntax %q", i+1, strings.TrimSpace(line))
+		}
+	}
+
+	fd, err := protodesc.NewFile(file, nil)
+	if err != nil {
+		t.Fatalf("build descriptor: %v", err)
+	}
+	return fd
+}
+
+// plain converts a message to JSON-friendly values keyed by proto field
+// name, failing on fields the schema doesn't know.
+func plain(t *testing.T, m protoreflect.Message) map[string]any {
+	t.Helper()
+
+	if len(m.GetUnknown()) > 0 {
+		t.Fatalf("%s: fields not in order.proto or with the wrong wire type", m.Descriptor().FullName())
+	}
+	out := make(map[string]any)
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		value := func(v protoreflect.Value) any {
+			if fd.Kind() == protoreflect.MessageKind {
+				return plain(t, v.Message())
+			}
+			return v.Interface()
+		}
+		if fd.IsList() {
+			var list []any
+			for i := 0; i < v.List().Len(); i++ {
+				list = append(list, value(v.List().Get(i)))
+			}
+			out[string(fd.Name())] = list
+		} else {
+			out[string(fd.Name())] = value(v)
+		}
+		return true
+	})
+	return out
+}
+
+// The hand-written encoding must match order.proto: decoded by the protobuf
+// runtime with that schema, every field lands under its model JSON name.
+func TestMarshal_MatchesOrderProto(t *testing.T) {
+	md := loadProto(t).Messages().ByName("Order")
+	want := sampleOrder()
+
+	m := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(Marshal(want), m); err != nil {
+		t.Fatalf("protobuf runtime can't read message: %v", err)
+	}
+	data, err := json.Marshal(plain(t, m))
+	if err != nil {
+		t.Fatalf("marshal json: %v", err)
+	}
+	var got model.Order
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal json: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("order.proto mismatch:\n got %+v\nwant %+v", got, want)
+	}
+
+	// And the other way round: what the runtime writes, Unmarshal reads.
+	b, err := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if got, err := Unmarshal(b); err != nil || !reflect.DeepEqual(got, want) {
+		t.Fatalf("runtime encoding mismatch: %v\n got %+v\nwant %+v", err, got, want)
+	}
+}
+
+func TestUnmarshal_IntOutOfRange(t *testing.T) {
+	if strconv.IntSize == 64 {
+		t.Skip("int holds every int64")
+	}
+	b := protowire.AppendTag(nil, 12, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1<<40)
+	if _, err := Unmarshal(b); err == nil {
+		t.Fatalf("expected error for sm_id out of int range")
+	}
+}
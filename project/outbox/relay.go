@@ -0,0 +1,108 @@
+package outbox
+
+import (
+	"awesomeProject3/project/codec"
+	"awesomeProject3/project/database"
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// EventTypeHeader and EventIDHeader let consumers route events and drop
+// the duplicates at-least-once delivery can produce.
+const (
+	EventTypeHeader = "event_type"
+	EventIDHeader   = "event_id"
+)
+
+type Store interface {
+	RelayOutbox(ctx context.Context, limit int, publish func([]database.OutboxEvent) error) (int, error)
+	CleanupOutbox(ctx context.Context, sentBefore time.Time) (int64, error)
+}
+
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Relay publishes outbox events to Kafka. An event is marked sent only after
+// the write is acknowledged, so a crash in between publishes it again.
+type Relay struct {
+	Store           Store
+	Writer          Writer
+	Interval        time.Duration
+	BatchSize       int
+	Retention       time.Duration
+	CleanupInterval time.Duration
+}
+
+func NewRelay(store Store, w Writer) *Relay {
+	return &Relay{
+		Store:           store,
+		Writer:          w,
+		Interval:        time.Second,
+		BatchSize:       100,
+		Retention:       24 * time.Hour,
+		CleanupInterval: time.Hour,
+	}
+}
+
+// Run relays events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+	cleanup := time.NewTicker(r.CleanupInterval)
+	defer cleanup.Stop()
+
+	log.Printf("Outbox relay started")
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := r.Drain(ctx); err != nil && ctx.Err() == nil {
+				log.Printf("Can't relay outbox events: %v", err)
+			}
+		case <-cleanup.C:
+			n, err := r.Store.CleanupOutbox(ctx, time.Now().Add(-r.Retention))
+			if err != nil {
+				log.Printf("Can't clean up outbox: %v", err)
+			} else if n > 0 {
+				log.Printf("Deleted %d sent outbox events", n)
+			}
+		}
+	}
+}
+
+// Drain relays batches until the outbox has no unsent events left.
+func (r *Relay) Drain(ctx context.Context) (int, error) {
+	total := 0
+	for {
+		n, err := r.Store.RelayOutbox(ctx, r.BatchSize, func(events []database.OutboxEvent) error {
+			return r.Writer.WriteMessages(ctx, messages(events)...)
+		})
+		total += n
+		if err != nil || n < r.BatchSize {
+			return total, err
+		}
+	}
+}
+
+func messages(events []database.OutboxEvent) []kafka.Message {
+	msgs := make([]kafka.Message, len(events))
+	for i, e := range events {
+		msgs[i] = kafka.Message{
+			Key:   []byte(e.AggregateID),
+			Value: e.Payload,
+			Headers: []kafka.Header{
+				{Key: codec.ContentTypeHeader, Value: []byte("application/json")},
+				{Key: EventTypeHeader, Value: []byte(e.Type)},
+				{Key: EventIDHeader, Value: []byte(strconv.FormatInt(e.ID, 10))},
+			},
+			Time: e.CreatedAt,
+		}
+	}
+	return msgs
+}
@@ -0,0 +1,88 @@
+package outbox
+
+import (
+	"awesomeProject3/project/database"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type mockWriter struct {
+	msgs []kafka.Message
+	err  error
+}
+
+func (w *mockWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.msgs = append(w.msgs, msgs...)
+	return nil
+}
+
+// outboxDB serves events from pending and marks them sent only when
+// publish succeeds, like the real RelayOutbox.
+func outboxDB(pending []database.OutboxEvent) *database.MockDB {
+	return &database.MockDB{RelayOutboxFunc: func(limit int, publish func([]database.OutboxEvent) error) (int, error) {
+		batch := pending
+		if len(batch) > limit {
+			batch = batch[:limit]
+		}
+		if len(batch) == 0 {
+			return 0, nil
+		}
+		if err := publish(batch); err != nil {
+			return 0, err
+		}
+		pending = pending[len(batch):]
+		return len(batch), nil
+	}}
+}
+
+func TestRelay_DrainPublishesAllBatches(t *testing.T) {
+	db := outboxDB([]database.OutboxEvent{
+		{ID: 1, AggregateID: "o-1", Type: "OrderStored", Payload: []byte(`{"order_uid":"o-1"}`)},
+		{ID: 2, AggregateID: "o-2", Type: "OrderStored", Payload: []byte(`{"order_uid":"o-2"}`)},
+		{ID: 3, AggregateID: "o-3", Type: "OrderStored", Payload: []byte(`{"order_uid":"o-3"}`)},
+	})
+	w := &mockWriter{}
+	r := NewRelay(db, w)
+	r.BatchSize = 2
+
+	n, err := r.Drain(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n != 3 || len(w.msgs) != 3 || db.RelayCalls != 2 {
+		t.Fatalf("expected 3 events in 2 batches, got %d events, %d messages, %d calls", n, len(w.msgs), db.RelayCalls)
+	}
+
+	msg := w.msgs[0]
+	if string(msg.Key) != "o-1" || string(msg.Value) != `{"order_uid":"o-1"}` {
+		t.Fatalf("expected message keyed by order, got %s %s", msg.Key, msg.Value)
+	}
+	headers := map[string]string{}
+	for _, h := range msg.Headers {
+		headers[h.Key] = string(h.Value)
+	}
+	if headers[EventTypeHeader] != "OrderStored" || headers[EventIDHeader] != "1" {
+		t.Fatalf("expected event headers, got %v", headers)
+	}
+}
+
+func TestRelay_PublishFailureKeepsEvents(t *testing.T) {
+	db := outboxDB([]database.OutboxEvent{{ID: 1, AggregateID: "o-1", Type: "OrderStored", Payload: []byte(`{}`)}})
+	w := &mockWriter{err: errors.New("kafka down")}
+	r := NewRelay(db, w)
+
+	if _, err := r.Drain(context.Background()); err == nil {
+		t.Fatalf("expected publish error")
+	}
+
+	w.err = nil
+	if n, err := r.Drain(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected event to be relayed on retry, got %d, %v", n, err)
+	}
+}
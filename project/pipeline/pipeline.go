@@ -0,0 +1,143 @@
+package pipeline
+
+import (
+	"awesomeProject3/project/model"
+	"context"
+	"errors"
+	"fmt"
+)
+
+const (
+	SourceKafka = "kafka"
+	SourceHTTP  = "http"
+	SourceFile  = "file"
+)
+
+// Envelope is the unit of work passed through the stages: the raw payload as
+// received (if any), the decoded order and where it came from.
+type Envelope struct {
+	Raw    []byte
+	Order  model.Order
+	Source string
+	Meta   map[string]string
+}
+
+type Stage interface {
+	Name() string
+	Process(ctx context.Context, env *Envelope) error
+}
+
+// BatchStage is implemented by stages that can handle several envelopes in
+// one call, e.g. to publish or insert them in a single round trip.
+type BatchStage interface {
+	Stage
+	ProcessBatch(ctx context.Context, envs []*Envelope) error
+}
+
+type StageError struct {
+	Stage    string
+	Err      error
+	rejected bool
+}
+
+func (e *StageError) Error() string { return fmt.Sprintf("%s: %v", e.Stage, e.Err) }
+
+func (e *StageError) Unwrap() error { return e.Err }
+
+type rejection struct{ err error }
+
+func (r rejection) Error() string { return r.err.Error() }
+
+func (r rejection) Unwrap() error { return r.err }
+
+// Reject marks err as permanent: retrying the same payload can't succeed, so
+// callers should drop it (or dead-letter it) instead of retrying.
+func Reject(err error) error {
+	if err == nil {
+		return nil
+	}
+	return rejection{err: err}
+}
+
+func IsRejected(err error) bool {
+	var se *StageError
+	if errors.As(err, &se) {
+		return se.rejected
+	}
+	var r rejection
+	return errors.As(err, &r)
+}
+
+// FailedStage reports the name of the stage that produced err, if any.
+func FailedStage(err error) string {
+	var se *StageError
+	if errors.As(err, &se) {
+		return se.Stage
+	}
+	return ""
+}
+
+type Pipeline struct {
+	stages []Stage
+}
+
+func New(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+func (p *Pipeline) Run(ctx context.Context, env *Envelope) error {
+	for _, st := range p.stages {
+		if err := st.Process(ctx, env); err != nil {
+			return wrap(st, err)
+		}
+	}
+	return nil
+}
+
+// RunBatch runs every envelope through the stages and returns one error per
+// envelope. An envelope that fails a stage is not passed to the next ones.
+// Batch stages receive all envelopes still in flight at once.
+func (p *Pipeline) RunBatch(ctx context.Context, envs []*Envelope) []error {
+	errs := make([]error, len(envs))
+
+	for _, st := range p.stages {
+		live := make([]int, 0, len(envs))
+		for i := range envs {
+			if errs[i] == nil {
+				live = append(live, i)
+			}
+		}
+		if len(live) == 0 {
+			break
+		}
+
+		if bs, ok := st.(BatchStage); ok {
+			batch := make([]*Envelope, len(live))
+			for j, i := range live {
+				batch[j] = envs[i]
+			}
+			if err := bs.ProcessBatch(ctx, batch); err != nil {
+				for _, i := range live {
+					errs[i] = wrap(st, err)
+				}
+			}
+			continue
+		}
+
+		for _, i := range live {
+			if err := st.Process(ctx, envs[i]); err != nil {
+				errs[i] = wrap(st, err)
+			}
+		}
+	}
+
+	return errs
+}
+
+func wrap(st Stage, err error) error {
+	var r rejection
+	if errors.As(err, &r) {
+		return &StageError{Stage: st.Name(), Err: r.err, rejected: true}
+	}
+	return &StageError{Stage: st.Name(), Err: err}
+}
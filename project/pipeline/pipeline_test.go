@@ -0,0 +1,145 @@
+package pipeline
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestDecode_InvalidJSON_Rejected(t *testing.T) {
+	env := &Envelope{Raw: []byte("{not-json")}
+
+	err := New(Decode(JSON)).Run(context.Background(), env)
+
+	if err == nil || !IsRejected(err) {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+	if FailedStage(err) != "decode" {
+		t.Fatalf("expected failed stage decode, got %q", FailedStage(err))
+	}
+}
+
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "registry unavailable" }
+func (temporaryError) Temporary() bool { return true }
+
+func TestDecode_TemporaryErrorIsNotRejection(t *testing.T) {
+	env := &Envelope{Raw: []byte("payload")}
+	decode := func(ctx context.Context, env *Envelope) (model.Order, error) {
+		return model.Order{}, fmt.Errorf("avro: %w", temporaryError{})
+	}
+
+	err := New(Decode(decode)).Run(context.Background(), env)
+
+	if err == nil || IsRejected(err) {
+		t.Fatalf("expected a retryable error, got %v", err)
+	}
+}
+
+func TestDecode_FillsOrder(t *testing.T) {
+	env := &Envelope{Raw: []byte(`{"order_uid":"d-1"}`)}
+
+	if err := Decode(JSON).Process(context.Background(), env); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Order.OrderUID != "d-1" {
+		t.Fatalf("expected order_uid d-1, got %q", env.Order.OrderUID)
+	}
+}
+
+func TestValidate_ErrorIsRejection(t *testing.T) {
+	st := Validate(func(o *model.Order) error { return errors.New("bad order") })
+
+	err := New(st).Run(context.Background(), &Envelope{})
+
+	if !IsRejected(err) {
+		t.Fatalf("expected rejection, got %v", err)
+	}
+}
+
+func TestPersist_ErrorIsNotRejection_StopsPipeline(t *testing.T) {
+	db := &database.MockDB{InsertOrderFunc: func(model.Order) error { return errors.New("db down") }}
+	ca := &cache.MockCache{}
+
+	err := New(Persist(db), Cache(ca)).Run(context.Background(), &Envelope{Order: model.Order{OrderUID: "p-1"}})
+
+	if err == nil || IsRejected(err) {
+		t.Fatalf("expected transient error, got %v", err)
+	}
+	if FailedStage(err) != "persist" {
+		t.Fatalf("expected failed stage persist, got %q", FailedStage(err))
+	}
+	if ca.SetCalls != 0 {
+		t.Fatalf("expected cache stage not to run after persist failure")
+	}
+}
+
+func TestPersistBatch_ReportsInsertedCount(t *testing.T) {
+	db := &database.MockDB{InsertOrdersFunc: func(orders []model.Order) (int, error) { return 1, nil }}
+	inserted := 0
+
+	envs := []*Envelope{{Order: model.Order{OrderUID: "a"}}, {Order: model.Order{OrderUID: "dup"}}}
+	errs := New(PersistBatch(db, func(n int) { inserted += n })).RunBatch(context.Background(), envs)
+
+	if errs[0] != nil || errs[1] != nil {
+		t.Fatalf("expected duplicates not to fail their envelopes, got %v", errs)
+	}
+	if inserted != 1 {
+		t.Fatalf("expected 1 inserted order, got %d", inserted)
+	}
+}
+
+func TestNotify_ErrorDoesNotFail(t *testing.T) {
+	st := Notify(func(ctx context.Context, env *Envelope) error { return errors.New("broker down") })
+
+	if err := New(st).Run(context.Background(), &Envelope{}); err != nil {
+		t.Fatalf("expected notify errors to be swallowed, got %v", err)
+	}
+}
+
+type recordingPublisher struct {
+	calls  int
+	orders []model.Order
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, orders ...model.Order) error {
+	p.calls++
+	p.orders = append(p.orders, orders...)
+	return nil
+}
+
+func TestRunBatch_SkipsFailedEnvelopes_BatchesPublish(t *testing.T) {
+	pub := &recordingPublisher{}
+	p := New(
+		Validate(func(o *model.Order) error {
+			if o.OrderUID == "bad" {
+				return errors.New("bad order")
+			}
+			return nil
+		}),
+		Publish(pub),
+	)
+
+	envs := []*Envelope{
+		{Order: model.Order{OrderUID: "a"}},
+		{Order: model.Order{OrderUID: "bad"}},
+		{Order: model.Order{OrderUID: "b"}},
+	}
+
+	errs := p.RunBatch(context.Background(), envs)
+
+	if errs[0] != nil || errs[2] != nil {
+		t.Fatalf("expected valid envelopes to succeed, got %v", errs)
+	}
+	if !IsRejected(errs[1]) {
+		t.Fatalf("expected invalid envelope to be rejected, got %v", errs[1])
+	}
+	if pub.calls != 1 || len(pub.orders) != 2 {
+		t.Fatalf("expected one publish call with 2 orders, got calls=%d orders=%d", pub.calls, len(pub.orders))
+	}
+}
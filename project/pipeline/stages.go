@@ -0,0 +1,161 @@
+package pipeline
+
+import (
+	"awesomeProject3/project/cache"
+	"awesomeProject3/project/database"
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/schema"
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+)
+
+type Publisher interface {
+	Publish(ctx context.Context, orders ...model.Order) error
+}
+
+type funcStage struct {
+	name string
+	fn   func(ctx context.Context, env *Envelope) error
+}
+
+func (s funcStage) Name() string { return s.name }
+
+func (s funcStage) Process(ctx context.Context, env *Envelope) error { return s.fn(ctx, env) }
+
+// Func adapts a plain function to a Stage.
+func Func(name string, fn func(ctx context.Context, env *Envelope) error) Stage {
+	return funcStage{name: name, fn: fn}
+}
+
+type DecodeFunc func(ctx context.Context, env *Envelope) (model.Order, error)
+
+// JSON decodes env.Raw, upcasting payloads of older schema versions.
+func JSON(ctx context.Context, env *Envelope) (model.Order, error) {
+	data, err := schema.Upcast(env.Raw, 0)
+	if err != nil {
+		return model.Order{}, err
+	}
+	var o model.Order
+	err = json.Unmarshal(data, &o)
+	return o, err
+}
+
+// Decode fills env.Order from env.Raw. Decoding failures are rejections,
+// except for errors reporting Temporary() == true, e.g. an unreachable schema
+// registry, which are returned as is so the envelope is retried.
+func Decode(decode DecodeFunc) Stage {
+	return Func("decode", func(ctx context.Context, env *Envelope) error {
+		if env.Raw == nil {
+			return Reject(errors.New("empty payload"))
+		}
+		o, err := decode(ctx, env)
+		var temp interface{ Temporary() bool }
+		if errors.As(err, &temp) && temp.Temporary() {
+			return err
+		}
+		if err != nil {
+			return Reject(err)
+		}
+		env.Order = o
+		return nil
+	})
+}
+
+// Validate rejects orders that fail validateFn. A nil validateFn accepts
+// everything.
+func Validate(validateFn func(*model.Order) error) Stage {
+	return Func("validate", func(ctx context.Context, env *Envelope) error {
+		if validateFn == nil {
+			return nil
+		}
+		return Reject(validateFn(&env.Order))
+	})
+}
+
+func Enrich(fn func(ctx context.Context, env *Envelope) error) Stage {
+	return Func("enrich", fn)
+}
+
+func Persist(db database.DB) Stage {
+	return Func("persist", func(ctx context.Context, env *Envelope) error {
+		return db.InsertOrder(env.Order)
+	})
+}
+
+type persistBatchStage struct {
+	db       database.DB
+	inserted func(n int)
+}
+
+// PersistBatch stores every envelope of a RunBatch call in one transaction.
+// Orders that are already stored don't fail their envelopes; inserted, if not
+// nil, is called with the number of orders each call actually stored.
+func PersistBatch(db database.DB, inserted func(n int)) Stage {
+	return persistBatchStage{db: db, inserted: inserted}
+}
+
+func (s persistBatchStage) Name() string { return "persist" }
+
+func (s persistBatchStage) Process(ctx context.Context, env *Envelope) error {
+	return s.insert([]model.Order{env.Order})
+}
+
+func (s persistBatchStage) ProcessBatch(ctx context.Context, envs []*Envelope) error {
+	orders := make([]model.Order, len(envs))
+	for i, env := range envs {
+		orders[i] = env.Order
+	}
+	return s.insert(orders)
+}
+
+func (s persistBatchStage) insert(orders []model.Order) error {
+	n, err := s.db.InsertOrders(orders)
+	if err == nil && s.inserted != nil {
+		s.inserted(n)
+	}
+	return err
+}
+
+func Cache(c cache.CC) Stage {
+	return Func("cache", func(ctx context.Context, env *Envelope) error {
+		c.Set(env.Order.OrderUID, env.Order)
+		return nil
+	})
+}
+
+// Notify runs fn after the order has been handled. The order is already
+// stored at this point, so failures are logged and never fail the envelope.
+func Notify(fn func(ctx context.Context, env *Envelope) error) Stage {
+	return Func("notify", func(ctx context.Context, env *Envelope) error {
+		if err := fn(ctx, env); err != nil {
+			log.Printf("Can't notify about order %s: %v", env.Order.OrderUID, err)
+		}
+		return nil
+	})
+}
+
+type publishStage struct {
+	p Publisher
+}
+
+// Publish hands orders to p instead of storing them, e.g. to route HTTP
+// ingestion through Kafka and the consumer.
+func Publish(p Publisher) Stage {
+	return publishStage{p: p}
+}
+
+func (s publishStage) Name() string { return "publish" }
+
+func (s publishStage) Process(ctx context.Context, env *Envelope) error {
+	return s.p.Publish(ctx, env.Order)
+}
+
+func (s publishStage) ProcessBatch(ctx context.Context, envs []*Envelope) error {
+	orders := make([]model.Order, len(envs))
+	for i, env := range envs {
+		orders[i] = env.Order
+	}
+	return s.p.Publish(ctx, orders...)
+}
@@ -0,0 +1,40 @@
+package producer
+
+import (
+	"awesomeProject3/project/codec"
+	"awesomeProject3/project/model"
+	"awesomeProject3/project/schema"
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+type Producer struct {
+	writer *kafka.Writer
+}
+
+func NewProducer(w *kafka.Writer) *Producer {
+	return &Producer{writer: w}
+}
+
+func (p *Producer) Publish(ctx context.Context, orders ...model.Order) error {
+	msgs := make([]kafka.Message, 0, len(orders))
+	for _, o := range orders {
+		o.SchemaVersion = schema.CurrentVersion
+		data, err := json.Marshal(o)
+		if err != nil {
+			return err
+		}
+		msgs = append(msgs, kafka.Message{
+			Key:   []byte(o.OrderUID),
+			Value: data,
+			Headers: []kafka.Header{
+				{Key: codec.ContentTypeHeader, Value: []byte("application/json")},
+				{Key: schema.VersionField, Value: []byte(strconv.Itoa(schema.CurrentVersion))},
+			},
+		})
+	}
+	return p.writer.WriteMessages(ctx, msgs...)
+}
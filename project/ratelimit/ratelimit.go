@@ -0,0 +1,166 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Per allows n requests per period, all of which may come at once.
+func Per(n int, period time.Duration) Limit {
+	return Limit{Rate: float64(n) / period.Seconds(), Burst: n}
+}
+
+// Window is the period over which Burst requests are allowed.
+func (l Limit) Window() time.Duration {
+	return time.Duration(float64(l.Burst) / l.Rate * float64(time.Second))
+}
+
+// Parse reads limits like "100/m", "10/s" or "1000/h".
+func Parse(s string) (Limit, error) {
+	n, unit, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("expected <count>/<s|m|h>, got %q", s)
+	}
+	count, err := strconv.Atoi(n)
+	if err != nil || count <= 0 {
+		return Limit{}, fmt.Errorf("bad count in %q", s)
+	}
+	periods := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}
+	period, ok := periods[unit]
+	if !ok {
+		return Limit{}, fmt.Errorf("bad period in %q", s)
+	}
+	return Per(count, period), nil
+}
+
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long to wait for the next token when not allowed.
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again.
+	Reset time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter keeps one bucket per key. Buckets that have refilled completely
+// are dropped by a janitor, so idle clients do not accumulate. Close stops
+// the janitor.
+type Limiter struct {
+	mu        sync.Mutex
+	limit     Limit
+	buckets   map[string]*bucket
+	now       func() time.Time
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func New(limit Limit) *Limiter {
+	l := &Limiter{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+		done:    make(chan struct{}),
+	}
+
+	interval := limit.Window()
+	if interval < time.Second {
+		interval = time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-l.done:
+				return
+			case <-ticker.C:
+				l.sweep()
+			}
+		}
+	}()
+	return l
+}
+
+// Close stops the janitor. The limiter keeps working, but idle buckets are
+// no longer dropped.
+func (l *Limiter) Close() {
+	l.closeOnce.Do(func() { close(l.done) })
+}
+
+// Allow takes a token from key's bucket if one is available.
+func (l *Limiter) Allow(key string) Result {
+	return l.take(key, true)
+}
+
+// Peek reports whether Allow would succeed without taking a token.
+func (l *Limiter) Peek(key string) Result {
+	return l.take(key, false)
+}
+
+func (l *Limiter) take(key string, consume bool) Result {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(now, l.limit)
+
+	res := Result{Limit: l.limit.Burst}
+	if b.tokens >= 1 {
+		if consume {
+			b.tokens--
+		}
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.seconds(1 - b.tokens)
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = l.seconds(float64(l.limit.Burst) - b.tokens)
+	return res
+}
+
+func (b *bucket) refill(now time.Time, limit Limit) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+}
+
+func (l *Limiter) seconds(tokens float64) time.Duration {
+	return time.Duration(tokens / l.limit.Rate * float64(time.Second))
+}
+
+func (l *Limiter) sweep() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	for key, b := range l.buckets {
+		b.refill(now, l.limit)
+		if b.tokens >= float64(l.limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestLimiter(limit Limit, now *time.Time) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		buckets: make(map[string]*bucket),
+		now:     func() time.Time { return *now },
+	}
+}
+
+func TestParse(t *testing.T) {
+	l, err := Parse("120/m")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.Burst != 120 || l.Rate != 2 || l.Window() != time.Minute {
+		t.Fatalf("expected 120 per minute, got %+v", l)
+	}
+	for _, s := range []string{"", "10", "0/s", "x/m", "10/d"} {
+		if _, err := Parse(s); err == nil {
+			t.Fatalf("expected error for %q", s)
+		}
+	}
+}
+
+func TestLimiter_AllowAndRefill(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newTestLimiter(Per(2, time.Second), &now)
+
+	for i := 0; i < 2; i++ {
+		if res := l.Allow("a"); !res.Allowed {
+			t.Fatalf("expected request %d to be allowed", i+1)
+		}
+	}
+	res := l.Allow("a")
+	if res.Allowed || res.Remaining != 0 {
+		t.Fatalf("expected third request to be limited, got %+v", res)
+	}
+	if res.RetryAfter != 500*time.Millisecond || res.Reset != time.Second {
+		t.Fatalf("expected retry after 500ms and reset in 1s, got %v and %v", res.RetryAfter, res.Reset)
+	}
+	if !l.Allow("b").Allowed {
+		t.Fatalf("expected other key to have its own bucket")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if !l.Allow("a").Allowed {
+		t.Fatalf("expected token to be refilled")
+	}
+}
+
+func TestLimiter_SweepDropsFullBuckets(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newTestLimiter(Per(1, time.Second), &now)
+	l.Allow("a")
+
+	l.sweep()
+	if len(l.buckets) != 1 {
+		t.Fatalf("expected empty bucket to be kept")
+	}
+	now = now.Add(time.Second)
+	l.sweep()
+	if len(l.buckets) != 0 {
+		t.Fatalf("expected refilled bucket to be dropped, got %d", len(l.buckets))
+	}
+}
+
+func TestLimiter_PeekDoesNotTakeTokens(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newTestLimiter(Per(1, time.Second), &now)
+
+	for i := 0; i < 3; i++ {
+		if !l.Peek("a").Allowed {
+			t.Fatalf("expected peek %d to be allowed", i+1)
+		}
+	}
+	l.Allow("a")
+	if res := l.Peek("a"); res.Allowed || res.RetryAfter != time.Second {
+		t.Fatalf("expected empty bucket to be reported, got %+v", res)
+	}
+}
+
+func TestLimiter_Close(t *testing.T) {
+	l := New(Per(1, time.Second))
+
+	l.Close()
+	l.Close()
+	if !l.Allow("a").Allowed {
+		t.Fatalf("expected limiter to keep working after Close")
+	}
+}
@@ -0,0 +1,80 @@
+package redact
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// JSON masks the tagged fields of a JSON document shaped like t, matching
+// keys case-insensitively like encoding/json. Unknown keys are kept.
+func JSON(data []byte, t reflect.Type) ([]byte, error) {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(maskJSON(doc, t))
+}
+
+func maskJSON(v any, t reflect.Type) any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return v
+		}
+		for key, val := range obj {
+			f, ok := jsonField(t, key)
+			if !ok {
+				continue
+			}
+			if kind, tagged := f.Tag.Lookup(Tag); tagged {
+				if s, ok := val.(string); ok {
+					obj[key] = Mask(kind, s)
+				} else if val != nil {
+					obj[key] = stars
+				}
+				continue
+			}
+			obj[key] = maskJSON(val, f.Type)
+		}
+	case reflect.Slice, reflect.Array:
+		arr, ok := v.([]any)
+		if !ok {
+			return v
+		}
+		for i, el := range arr {
+			arr[i] = maskJSON(el, t.Elem())
+		}
+	}
+	return v
+}
+
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var folded reflect.StructField
+	found := false
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		if name == key {
+			return f, true
+		}
+		if !found && strings.EqualFold(name, key) {
+			folded, found = f, true
+		}
+	}
+	return folded, found
+}
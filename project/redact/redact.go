@@ -0,0 +1,190 @@
+package redact
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Tag is the struct tag naming how a field is masked, e.g. `pii:"phone"`.
+const Tag = "pii"
+
+const (
+	KindName  = "name"
+	KindPhone = "phone"
+	KindEmail = "email"
+	KindFull  = "full"
+)
+
+const stars = "***"
+
+// Mask masks s according to kind. Unknown kinds are masked fully.
+func Mask(kind, s string) string {
+	switch kind {
+	case KindName:
+		return Name(s)
+	case KindPhone:
+		return Phone(s)
+	case KindEmail:
+		return Email(s)
+	}
+	return Full(s)
+}
+
+// Phone keeps the country prefix and the last two digits:
+// "+79161234512" becomes "+7********12".
+func Phone(s string) string {
+	var digits []rune
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			digits = append(digits, r)
+		}
+	}
+	if len(digits) < 5 {
+		return Full(s)
+	}
+
+	var b strings.Builder
+	if strings.HasPrefix(strings.TrimSpace(s), "+") {
+		b.WriteByte('+')
+	}
+	b.WriteRune(digits[0])
+	b.WriteString(strings.Repeat("*", len(digits)-3))
+	b.WriteString(string(digits[len(digits)-2:]))
+	return b.String()
+}
+
+// Email keeps the first letter and the domain: "t***@gmail.com".
+func Email(s string) string {
+	local, domain, ok := strings.Cut(s, "@")
+	if !ok || local == "" {
+		return Full(s)
+	}
+	r, _ := utf8.DecodeRuneInString(local)
+	return string(r) + stars + "@" + domain
+}
+
+// Name keeps the initial of every word: "T*** T***".
+func Name(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		r, _ := utf8.DecodeRuneInString(w)
+		words[i] = string(r) + stars
+	}
+	return strings.Join(words, " ")
+}
+
+func Full(s string) string {
+	if s == "" {
+		return ""
+	}
+	return stars
+}
+
+// Value returns a copy of v with every tagged string field masked. v itself
+// is not modified; slices are copied only when their elements need masking.
+func Value[T any](v T) T {
+	out := reflect.New(reflect.TypeOf(&v).Elem()).Elem()
+	out.Set(reflect.ValueOf(&v).Elem())
+	mask(out)
+	return out.Interface().(T)
+}
+
+func mask(v reflect.Value) {
+	if !hasPII(v.Type()) {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() || !v.CanSet() {
+			return
+		}
+		inner := reflect.New(v.Elem().Type()).Elem()
+		inner.Set(v.Elem())
+		mask(inner)
+		v.Set(inner)
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := v.Field(i)
+			if !f.CanSet() {
+				continue
+			}
+			if kind, ok := t.Field(i).Tag.Lookup(Tag); ok && f.Kind() == reflect.String {
+				f.SetString(Mask(kind, f.String()))
+				continue
+			}
+			mask(f)
+		}
+	case reflect.Slice:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(cp, v)
+		for i := 0; i < cp.Len(); i++ {
+			mask(cp.Index(i))
+		}
+		v.Set(cp)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			mask(v.Index(i))
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		cp := reflect.New(v.Type().Elem())
+		cp.Elem().Set(v.Elem())
+		mask(cp.Elem())
+		v.Set(cp)
+	}
+}
+
+var piiTypes sync.Map
+
+// hasPII reports whether values of t can contain tagged fields. Interfaces
+// are always inspected.
+func hasPII(t reflect.Type) bool {
+	if v, ok := piiTypes.Load(t); ok {
+		return v.(bool)
+	}
+	found := findPII(t, make(map[reflect.Type]bool))
+	piiTypes.Store(t, found)
+	return found
+}
+
+// findPII walks t depth first. A type already on the path counts as having no
+// PII: anything reachable through it is reached by the rest of the walk.
+// Because of that, only the result for the root type is worth caching.
+func findPII(t reflect.Type, visiting map[reflect.Type]bool) bool {
+	if v, ok := piiTypes.Load(t); ok {
+		return v.(bool)
+	}
+	if visiting[t] {
+		return false
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			if _, tagged := f.Tag.Lookup(Tag); tagged || findPII(f.Type, visiting) {
+				return true
+			}
+		}
+	case reflect.Slice, reflect.Array, reflect.Pointer:
+		return findPII(t.Elem(), visiting)
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type contact struct {
+	Name  string `json:"name" pii:"name"`
+	Phone string `json:"phone" pii:"phone"`
+	Email string `json:"email" pii:"email"`
+	City  string `json:"city"`
+}
+
+type node struct {
+	Next    *node   `json:"next"`
+	Contact contact `json:"contact"`
+}
+
+type envelope struct {
+	Contacts []contact `json:"contacts"`
+	Main     contact   `json:"main"`
+	Note     string    `json:"note"`
+}
+
+func TestMask_Formats(t *testing.T) {
+	cases := []struct {
+		kind, in, want string
+	}{
+		{KindPhone, "+79161234512", "+7********12"},
+		{KindPhone, "+7 (916) 123-45-12", "+7********12"},
+		{KindPhone, "123", "***"},
+		{KindEmail, "test@gmail.com", "t***@gmail.com"},
+		{KindEmail, "broken", "***"},
+		{KindName, "Test Testov", "T*** T***"},
+		{KindFull, "Ploshad Mira 15", "***"},
+		{KindFull, "", ""},
+	}
+
+	for _, tc := range cases {
+		if got := Mask(tc.kind, tc.in); got != tc.want {
+			t.Fatalf("Mask(%q, %q) = %q, want %q", tc.kind, tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestValue_CopiesAndMasks(t *testing.T) {
+	in := envelope{
+		Contacts: []contact{{Name: "Ivan Petrov", Phone: "+79161234512", City: "Moscow"}},
+		Main:     contact{Email: "ivan@example.com"},
+		Note:     "ivan@example.com",
+	}
+
+	out := Value(in)
+
+	if out.Contacts[0].Name != "I*** P***" || out.Contacts[0].City != "Moscow" {
+		t.Fatalf("unexpected masked contact: %+v", out.Contacts[0])
+	}
+	if out.Main.Email != "i***@example.com" || out.Note != in.Note {
+		t.Fatalf("expected only tagged fields to be masked, got %+v", out)
+	}
+	if in.Contacts[0].Name != "Ivan Petrov" {
+		t.Fatalf("expected input slice to be left alone, got %+v", in.Contacts[0])
+	}
+
+	var boxed any = in
+	if got := Value(boxed).(envelope); got.Main.Email != "i***@example.com" {
+		t.Fatalf("expected value behind interface to be masked, got %+v", got)
+	}
+}
+
+func TestValue_RecursiveType(t *testing.T) {
+	in := node{Next: &node{Contact: contact{Email: "ivan@example.com"}}}
+
+	out := Value(in)
+
+	if out.Next.Contact.Email != "i***@example.com" {
+		t.Fatalf("expected nested node to be masked, got %+v", out.Next.Contact)
+	}
+}
+
+func TestJSON_MasksKnownPaths(t *testing.T) {
+	data := []byte(`{"main":{"Phone":"+79161234512","city":"Moscow"},"contacts":[{"email":"a@b.io"}],"extra":{"phone":"+79161234512"}}`)
+
+	out, err := JSON(data, reflect.TypeOf(envelope{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got map[string]any
+	_ = json.Unmarshal(out, &got)
+	if got["main"].(map[string]any)["Phone"] != "+7********12" {
+		t.Fatalf("expected case-insensitive match to be masked, got %s", out)
+	}
+	if got["contacts"].([]any)[0].(map[string]any)["email"] != "a***@b.io" {
+		t.Fatalf("expected slice element to be masked, got %s", out)
+	}
+	if got["extra"].(map[string]any)["phone"] != "+79161234512" {
+		t.Fatalf("expected unknown keys to be kept, got %s", out)
+	}
+}
+
+func TestWriter_MasksLogLines(t *testing.T) {
+	var buf bytes.Buffer
+	l := log.New(NewWriter(&buf), "", 0)
+
+	l.Printf("Can't insert order 9934930 for test@gmail.com, phone +9720000000")
+
+	got := buf.String()
+	if strings.Contains(got, "test@gmail.com") || strings.Contains(got, "+9720000000") {
+		t.Fatalf("expected contacts to be masked, got %q", got)
+	}
+	if !strings.Contains(got, "9934930") {
+		t.Fatalf("expected plain numbers to be kept, got %q", got)
+	}
+}
@@ -0,0 +1,36 @@
+package redact
+
+import (
+	"io"
+	"regexp"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	// Only numbers written with a leading "+" are treated as phones, so
+	// order IDs and timestamps are left alone.
+	phonePattern = regexp.MustCompile(`\+\d[\d\s()-]{6,}\d`)
+)
+
+// Text masks e-mail addresses and phone numbers in free text.
+func Text(s string) string {
+	s = emailPattern.ReplaceAllStringFunc(s, Email)
+	return phonePattern.ReplaceAllStringFunc(s, Phone)
+}
+
+type writer struct {
+	w io.Writer
+}
+
+// NewWriter masks e-mails and phones in everything written through it. It is
+// meant for log.SetOutput, which writes one line per call.
+func NewWriter(w io.Writer) io.Writer {
+	return writer{w: w}
+}
+
+func (w writer) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(w.w, Text(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
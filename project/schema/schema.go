@@ -0,0 +1,140 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// CurrentVersion is the order payload version written by this service.
+const CurrentVersion = 2
+
+// VersionField is the payload field and Kafka header carrying the version.
+// Payloads without it predate versioning and are treated as version 1.
+const VersionField = "schema_version"
+
+// Upcaster migrates a decoded JSON document from one version to the next in
+// place.
+type Upcaster func(doc map[string]any) error
+
+type Registry struct {
+	current   int
+	upcasters map[int]Upcaster
+}
+
+func NewRegistry(current int) *Registry {
+	return &Registry{current: current, upcasters: make(map[int]Upcaster)}
+}
+
+// Register adds the upcaster migrating documents from version from to from+1.
+// A nil fn means the format did not change and only the version is bumped.
+func (r *Registry) Register(from int, fn Upcaster) {
+	r.upcasters[from] = fn
+}
+
+var Default = func() *Registry {
+	r := NewRegistry(CurrentVersion)
+	// Version 1 is the original payload, written before orders carried
+	// schema_version. Its fields are the same as in version 2.
+	r.Register(1, nil)
+	return r
+}()
+
+func Upcast(data []byte, version int) ([]byte, error) {
+	return Default.Upcast(data, version)
+}
+
+// Upcast migrates a JSON order document to the current version. version is
+// the version announced out of band (e.g. a Kafka header); zero means it is
+// read from the document itself.
+func (r *Registry) Upcast(data []byte, version int) ([]byte, error) {
+	if version == 0 {
+		v, err := DetectVersion(data)
+		if err != nil {
+			return nil, err
+		}
+		version = v
+	}
+	if version == r.current {
+		return data, nil
+	}
+	if version > r.current {
+		return nil, fmt.Errorf("schema version %d is newer than supported version %d", version, r.current)
+	}
+
+	changed := false
+	for v := version; v < r.current; v++ {
+		up, ok := r.upcasters[v]
+		if !ok {
+			return nil, fmt.Errorf("no upcaster from schema version %d", v)
+		}
+		changed = changed || up != nil
+	}
+	if !changed {
+		return stamp(data, r.current)
+	}
+
+	// UseNumber keeps integers above 2^53 intact.
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc map[string]any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	for v := version; v < r.current; v++ {
+		if up := r.upcasters[v]; up != nil {
+			if err := up(doc); err != nil {
+				return nil, fmt.Errorf("upcast v%d to v%d: %w", v, v+1, err)
+			}
+		}
+	}
+	doc[VersionField] = r.current
+
+	return json.Marshal(doc)
+}
+
+// stamp sets the version of a JSON object without decoding it. The field is
+// appended because a repeated key overrides earlier ones when unmarshalled.
+func stamp(data []byte, version int) ([]byte, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) < 2 || trimmed[0] != '{' || trimmed[len(trimmed)-1] != '}' {
+		return nil, fmt.Errorf("schema: payload is not a JSON object")
+	}
+	body := bytes.TrimSpace(trimmed[1 : len(trimmed)-1])
+
+	out := make([]byte, 0, len(trimmed)+32)
+	out = append(out, '{')
+	out = append(out, body...)
+	if len(body) > 0 {
+		out = append(out, ',')
+	}
+	out = fmt.Appendf(out, `"%s":%d}`, VersionField, version)
+	return out, nil
+}
+
+func DetectVersion(data []byte) (int, error) {
+	var probe struct {
+		Version json.RawMessage `json:"schema_version"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return 0, err
+	}
+	if len(probe.Version) == 0 || string(probe.Version) == "null" {
+		return 1, nil
+	}
+	return ParseVersion(string(probe.Version))
+}
+
+func ParseVersion(s string) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		if uq, uerr := strconv.Unquote(s); uerr == nil {
+			v, err = strconv.Atoi(uq)
+		}
+	}
+	if err != nil || v < 1 {
+		return 0, fmt.Errorf("invalid schema version %q", s)
+	}
+	return v, nil
+}
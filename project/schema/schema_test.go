@@ -0,0 +1,133 @@
+package schema
+
+import (
+	"awesomeProject3/project/model"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+func decode(t *testing.T, data []byte, version int) model.Order {
+	t.Helper()
+
+	up, err := Upcast(data, version)
+	if err != nil {
+		t.Fatalf("upcast: %v", err)
+	}
+	var o model.Order
+	if err := json.Unmarshal(up, &o); err != nil {
+		t.Fatalf("unmarshal upcasted payload: %v", err)
+	}
+	return o
+}
+
+func TestUpcast_AllFixturesDecodeToSameOrder(t *testing.T) {
+	want := decode(t, loadFixture(t, "order_v2.json"), 0)
+	if want.SchemaVersion != CurrentVersion {
+		t.Fatalf("expected current version %d, got %d", CurrentVersion, want.SchemaVersion)
+	}
+
+	got := decode(t, loadFixture(t, "order_v1.json"), 0)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("upcasted order differs:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestUpcast_CurrentVersionIsUnchanged(t *testing.T) {
+	data := loadFixture(t, "order_v2.json")
+
+	got, err := Upcast(data, 0)
+	if err != nil {
+		t.Fatalf("upcast: %v", err)
+	}
+	if &got[0] != &data[0] {
+		t.Fatalf("expected current version payload to be passed through")
+	}
+}
+
+func TestUpcast_HeaderVersionOverridesDetection(t *testing.T) {
+	data := loadFixture(t, "order_v2.json")
+
+	got := decode(t, data, 1)
+	if got.SchemaVersion != CurrentVersion || got.Payment.Amount != 1817 {
+		t.Fatalf("expected upcasted order with amount 1817, got %+v", got)
+	}
+	if _, err := Upcast(data, CurrentVersion+1); err == nil {
+		t.Fatalf("expected header version to be used instead of the payload's")
+	}
+}
+
+func TestUpcast_FutureVersion(t *testing.T) {
+	if _, err := Upcast([]byte(`{"schema_version": 99}`), 0); err == nil {
+		t.Fatalf("expected error for unsupported future version")
+	}
+}
+
+func TestUpcast_InvalidVersion(t *testing.T) {
+	if _, err := Upcast([]byte(`{"schema_version": "two"}`), 0); err == nil {
+		t.Fatalf("expected error for invalid version")
+	}
+}
+
+func TestRegistry_ChainsUpcasters(t *testing.T) {
+	r := NewRegistry(3)
+	r.Register(1, func(doc map[string]any) error { doc["entry"] = "v2"; return nil })
+	r.Register(2, func(doc map[string]any) error { doc["entry"] = doc["entry"].(string) + "+v3"; return nil })
+
+	out, err := r.Upcast([]byte(`{"order_uid": "x"}`), 0)
+	if err != nil {
+		t.Fatalf("upcast: %v", err)
+	}
+	var doc map[string]any
+	_ = json.Unmarshal(out, &doc)
+	if doc["entry"] != "v2+v3" || doc["schema_version"] != float64(3) {
+		t.Fatalf("unexpected document: %v", doc)
+	}
+}
+
+func TestUpcast_NoOpChainOnlyStampsVersion(t *testing.T) {
+	got, err := Upcast([]byte(` {"payment_dt": 9007199254740993} `), 0)
+	if err != nil {
+		t.Fatalf("upcast: %v", err)
+	}
+	if string(got) != `{"payment_dt": 9007199254740993,"schema_version":2}` {
+		t.Fatalf("expected payload to be stamped as is, got %s", got)
+	}
+
+	got, err = Upcast([]byte(`{}`), 1)
+	if err != nil || string(got) != `{"schema_version":2}` {
+		t.Fatalf("expected empty object to be stamped, got %s %v", got, err)
+	}
+	if _, err := Upcast([]byte(`[]`), 1); err == nil {
+		t.Fatalf("expected error for non-object payload")
+	}
+}
+
+func TestRegistry_KeepsLargeIntegers(t *testing.T) {
+	r := NewRegistry(2)
+	r.Register(1, func(doc map[string]any) error { doc["entry"] = "v2"; return nil })
+
+	out, err := r.Upcast([]byte(`{"payment":{"payment_dt":9007199254740993}}`), 0)
+	if err != nil {
+		t.Fatalf("upcast: %v", err)
+	}
+	var o model.Order
+	if err := json.Unmarshal(out, &o); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if o.Payment.PaymentDT != 9007199254740993 {
+		t.Fatalf("expected 9007199254740993, got %d", o.Payment.PaymentDT)
+	}
+}
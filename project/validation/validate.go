@@ -2,12 +2,112 @@ package validation
 
 import (
 	"awesomeProject3/project/model"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
 
-var validate = validator.New()
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	return v
+}
 
 func ValidateOrder(o *model.Order) error {
 	return validate.Struct(o)
 }
+
+// Validation profiles. ProfileMinimal only checks the identifiers an order
+// can't be stored or found without.
+const (
+	ProfileFull    = "full"
+	ProfileMinimal = "minimal"
+	ProfileNone    = "none"
+)
+
+func validateMinimal(o *model.Order) error {
+	return validate.StructPartial(o, "OrderUID", "TrackNumber", "CustomerID")
+}
+
+// Profile returns the validation function of a profile; "" means full.
+func Profile(name string) (func(*model.Order) error, error) {
+	switch name {
+	case "", ProfileFull:
+		return ValidateOrder, nil
+	case ProfileMinimal:
+		return validateMinimal, nil
+	case ProfileNone:
+		return func(*model.Order) error { return nil }, nil
+	}
+	return nil, fmt.Errorf("unknown validation profile %q", name)
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Describe turns a validation error into per-field errors addressed by their
+// JSON path, e.g. "delivery.email". Errors of other kinds are returned as a
+// single entry without a field.
+func Describe(err error) []FieldError {
+	if err == nil {
+		return nil
+	}
+
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return []FieldError{{Message: err.Error()}}
+	}
+
+	out := make([]FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		field := fe.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+		out = append(out, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message(field, fe),
+		})
+	}
+	return out
+}
+
+func message(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email", field)
+	case "len":
+		return fmt.Sprintf("%s must be exactly %s characters long", field, fe.Param())
+	case "min":
+		return fmt.Sprintf("%s must contain at least %s elements", field, fe.Param())
+	case "max":
+		return fmt.Sprintf("%s must be at most %s characters long", field, fe.Param())
+	case "gt":
+		return fmt.Sprintf("%s must be greater than %s", field, fe.Param())
+	case "gte":
+		return fmt.Sprintf("%s must be greater than or equal to %s", field, fe.Param())
+	case "lte":
+		return fmt.Sprintf("%s must be less than or equal to %s", field, fe.Param())
+	default:
+		return fmt.Sprintf("%s failed %q validation", field, fe.Tag())
+	}
+}